@@ -1,9 +1,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"go-api/config"
+	"go-api/internal/achievements"
+	"go-api/internal/analytics"
+	"go-api/internal/export"
+	"go-api/internal/feed"
+	"go-api/internal/handlers"
+	"go-api/internal/middleware"
+	"go-api/internal/outbox"
+	"go-api/internal/recommend"
 	"go-api/internal/routes"
+	"go-api/internal/rpc"
+	"go-api/internal/search"
+	"go-api/internal/species"
+	"go-api/internal/spriteproxy"
+	"go-api/internal/storage"
+	"go-api/internal/usage"
+	"go-api/internal/webhooks"
+	"log"
+	"strconv"
+	"time"
 )
 
 // @title           PokeAPI
@@ -28,6 +47,91 @@ func main() {
 	config.LoadEnv()
 	config.ConnectDatabase()
 
+	// Set up the blob store for uploads
+	if err := storage.Setup(); err != nil {
+		log.Fatal("Failed to set up blob store:", err)
+	}
+
+	// Set up the external sprite proxy cache
+	if err := spriteproxy.Setup(); err != nil {
+		log.Fatal("Failed to set up sprite proxy:", err)
+	}
+
+	// Seed the species catalog
+	speciesFile := config.GetEnv("SPECIES_FILE")
+	if speciesFile == "" {
+		speciesFile = "config/species.json" // Default catalog
+	}
+	if err := species.Seed(config.DB, speciesFile); err != nil {
+		log.Println("Failed to seed species:", err)
+	}
+
+	// Index the favorites and species for the search
+	if err := search.Setup(config.DB); err != nil {
+		log.Println("Failed to set up search:", err)
+	}
+
+	// Load achievement rules and listen to domain events
+	achievementsFile := config.GetEnv("ACHIEVEMENTS_FILE")
+	if achievementsFile == "" {
+		achievementsFile = "config/achievements.json" // Default rules
+	}
+	if err := achievements.LoadRules(achievementsFile); err != nil {
+		log.Println("Failed to load achievement rules:", err)
+	}
+	achievements.Register()
+
+	// Push the domain events to the change feed
+	replaySize, err := strconv.Atoi(config.GetEnv("FEED_REPLAY_SIZE"))
+	if err != nil || replaySize <= 0 {
+		replaySize = 1000 // Default replay buffer
+	}
+	feed.Register(replaySize)
+
+	// Queue and deliver the webhooks
+	webhooks.Register()
+	go webhooks.Start(context.Background(), config.DB, webhooks.LoadSettings())
+
+	// Relay the committed outbox events to the shared subscribers above, and
+	// hand the published ones to the local subscribers of this instance
+	go outbox.Start(context.Background(), config.DB, outbox.LoadSettings(), outbox.Buses(false)...)
+	go outbox.Follow(context.Background(), config.DB, outbox.LoadSettings(), outbox.Buses(true)...)
+
+	// Compute the species neighbors behind the recommendations
+	go recommend.Start(context.Background(), config.DB, recommend.LoadSettings())
+
+	// Roll up the favorites for the analytics
+	go analytics.Start(context.Background(), config.DB, analytics.LoadSettings())
+
+	// Redact the secrets of the requests logged before the logger did
+	go func() {
+		if err := middleware.RedactStoredLogs(config.DB); err != nil {
+			log.Println("Failed to redact the request log:", err)
+		}
+	}()
+
+	// Delete the expired idempotency keys
+	sweepInterval := config.GetEnvDuration("IDEMPOTENCY_SWEEP_INTERVAL", time.Hour) // Default hourly
+	go middleware.SweepIdempotencyKeys(context.Background(), config.DB, sweepInterval)
+
+	// Roll up the request log for the usage reports
+	go usage.Start(context.Background(), config.DB, usage.LoadSettings())
+
+	// Resume the export jobs interrupted by the last shutdown
+	export.Resume(config.DB, handlers.ExportSource)
+
+	// Start the gRPC server beside Gin
+	grpcPort := config.GetEnv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090" // Default gRPC port
+	}
+	go func() {
+		fmt.Println("🚀 gRPC server running on port", grpcPort)
+		if err := rpc.Serve(grpcPort); err != nil {
+			log.Fatal("Failed to serve gRPC:", err)
+		}
+	}()
+
 	// Start the Gin server
 	r := routes.SetupRoutes()
 	port := config.GetEnv("PORT")
@@ -0,0 +1,47 @@
+// Command webhookreceiver is a local endpoint to try the webhooks against.
+// It prints every delivery, checks its signature and answers with
+// WEBHOOK_RECEIVER_STATUS so failures and retries can be tried too.
+//
+//	WEBHOOK_SECRET=whsec_... go run ./cmd/webhookreceiver
+package main
+
+import (
+	"fmt"
+	"go-api/config"
+	"go-api/internal/webhooks"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func main() {
+	config.LoadEnv()
+
+	port := config.GetEnv("WEBHOOK_RECEIVER_PORT")
+	if port == "" {
+		port = "9999" // Default port
+	}
+	status, err := strconv.Atoi(config.GetEnv("WEBHOOK_RECEIVER_STATUS"))
+	if err != nil {
+		status = http.StatusOK // Default status
+	}
+	secret := config.GetEnv("WEBHOOK_SECRET")
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified := webhooks.Verify(secret, r.Header.Get(webhooks.TimestampHeader), r.Header.Get(webhooks.SignatureHeader), body, 5*time.Minute)
+		log.Printf("delivery %s | %s | signature valid: %t\n%s",
+			r.Header.Get(webhooks.DeliveryHeader), r.Header.Get(webhooks.EventHeader), verified, body)
+		if !verified {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprintln(w, "received")
+	})
+
+	fmt.Println("🪝 Webhook receiver listening on port", port)
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}
@@ -39,6 +39,40 @@ func ConnectDatabase() {
 	DB.AutoMigrate(&models.User{})
 	DB.AutoMigrate(&models.Pokemon{})
 	DB.AutoMigrate(&models.Log{})
+	DB.AutoMigrate(&models.Species{})
+	DB.AutoMigrate(&models.Tag{})
+	DB.AutoMigrate(&models.Box{})
+	DB.AutoMigrate(&models.SpriteUpload{})
+	DB.AutoMigrate(&models.UserAchievement{})
+	DB.AutoMigrate(&models.ExportJob{})
+	DB.AutoMigrate(&models.IdempotencyKey{})
+	DB.AutoMigrate(&models.Webhook{})
+	DB.AutoMigrate(&models.WebhookDelivery{})
+	DB.AutoMigrate(&models.OutboxEvent{})
+	DB.AutoMigrate(&models.OutboxLease{})
+	DB.AutoMigrate(&models.SpeciesNeighbor{})
+	DB.AutoMigrate(&models.AnalyticsFavoriteDay{})
+	DB.AutoMigrate(&models.AnalyticsUserDay{})
+	DB.AutoMigrate(&models.AnalyticsRollup{})
+	DB.AutoMigrate(&models.RequestHour{})
+	DB.AutoMigrate(&models.ClientHour{})
+
+	// Full-text index of the log bodies for the log explorer
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_logs_search ON logs USING GIN (" + models.LogSearchVector + ")").Error; err != nil {
+		log.Println("Failed to create the log search index:", err)
+	}
+
+	// Index of the favorite changes for the incremental analytics refresh,
+	// deleted_at is already indexed by gorm.Model
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_pokemons_updated_at ON pokemons (updated_at)").Error; err != nil {
+		log.Println("Failed to create the pokemon changes index:", err)
+	}
+
+	// Boxes are deleted for good, the ones soft deleted before still hold
+	// their names in idx_box_user_name
+	if err := DB.Exec("DELETE FROM boxes WHERE deleted_at IS NOT NULL").Error; err != nil {
+		log.Println("Failed to purge the deleted boxes:", err)
+	}
 
 	fmt.Println("✅ Successfully connected to the database!")
 }
@@ -0,0 +1,194 @@
+package achievements
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api/config"
+	"go-api/internal/events"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Rule kinds supported by the engine
+const (
+	KindFavoriteCount = "favorite_count"
+	KindCollection    = "collection"
+)
+
+// Rule is a declarative achievement definition loaded from the rules file
+type Rule struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Kind        string   `json:"kind"`
+	Type        string   `json:"type,omitempty"`
+	Min         int      `json:"min,omitempty"`
+	Names       []string `json:"names,omitempty"`
+}
+
+// favorite holds the columns needed to evaluate rules
+type favorite struct {
+	Name string
+	Type string
+}
+
+var (
+	mu    sync.RWMutex
+	rules []Rule
+)
+
+// LoadRules reads and validates the rules file
+func LoadRules(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var loaded []Rule
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		return fmt.Errorf("invalid achievements file: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, rule := range loaded {
+		if rule.Code == "" {
+			return fmt.Errorf("achievement rule without code")
+		}
+		if seen[rule.Code] {
+			return fmt.Errorf("duplicate achievement code %q", rule.Code)
+		}
+		seen[rule.Code] = true
+
+		switch rule.Kind {
+		case KindFavoriteCount:
+			if rule.Min <= 0 {
+				return fmt.Errorf("achievement %q: min must be positive", rule.Code)
+			}
+		case KindCollection:
+			if len(rule.Names) == 0 {
+				return fmt.Errorf("achievement %q: names must not be empty", rule.Code)
+			}
+		default:
+			return fmt.Errorf("achievement %q: unknown kind %q", rule.Code, rule.Kind)
+		}
+	}
+
+	mu.Lock()
+	rules = loaded
+	mu.Unlock()
+	return nil
+}
+
+// Rules returns the loaded rules
+func Rules() []Rule {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Rule(nil), rules...)
+}
+
+// Register subscribes the engine to the domain events it reacts to
+func Register() {
+	events.Subscribe("achievements", func(e events.Event) error {
+		if e.UserEmail == "" {
+			return nil
+		}
+		if _, err := Evaluate(config.DB, e.UserEmail); err != nil {
+			return fmt.Errorf("failed to evaluate %s: %w", e.UserEmail, err)
+		}
+		return nil
+	}, events.PokemonCreated, events.PokemonUpdated)
+}
+
+// Evaluate checks every rule for the user and awards the missing badges.
+// Awards are idempotent, so it is safe to call repeatedly.
+func Evaluate(db *gorm.DB, email string) ([]models.UserAchievement, error) {
+	var favorites []favorite
+	if err := db.Model(&models.Pokemon{}).
+		Select("name, type").
+		Where("user_email = ?", email).
+		Find(&favorites).Error; err != nil {
+		return nil, err
+	}
+
+	var awarded []models.UserAchievement
+	for _, rule := range Rules() {
+		if !matches(rule, favorites) {
+			continue
+		}
+
+		achievement := models.UserAchievement{
+			UserEmail: email,
+			Code:      rule.Code,
+			AwardedAt: time.Now(),
+		}
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&achievement)
+		if result.Error != nil {
+			return awarded, result.Error
+		}
+		if result.RowsAffected > 0 {
+			awarded = append(awarded, achievement)
+		}
+	}
+	return awarded, nil
+}
+
+// Backfill evaluates the rules for every user that owns favorites
+func Backfill(db *gorm.DB) (int, error) {
+	var emails []string
+	if err := db.Model(&models.Pokemon{}).Distinct().Pluck("user_email", &emails).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, email := range emails {
+		awarded, err := Evaluate(db, email)
+		total += len(awarded)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func matches(rule Rule, favorites []favorite) bool {
+	switch rule.Kind {
+	case KindFavoriteCount:
+		count := 0
+		for _, f := range favorites {
+			if rule.Type == "" || hasType(f.Type, rule.Type) {
+				count++
+			}
+		}
+		return count >= rule.Min
+
+	case KindCollection:
+		owned := map[string]bool{}
+		for _, f := range favorites {
+			owned[strings.ToLower(strings.TrimSpace(f.Name))] = true
+		}
+		for _, name := range rule.Names {
+			if !owned[strings.ToLower(name)] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hasType reports whether a type column like "fire/flying" contains the type
+func hasType(value, wanted string) bool {
+	for _, t := range strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == ',' }) {
+		if strings.EqualFold(strings.TrimSpace(t), wanted) {
+			return true
+		}
+	}
+	return false
+}
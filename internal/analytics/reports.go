@@ -0,0 +1,218 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Buckets of the series
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week" // starting on monday
+	BucketMonth = "month"
+)
+
+// ErrInvalidBucket is returned for a bucket other than day, week or month
+var ErrInvalidBucket = errors.New("bucket must be day, week or month")
+
+// ErrRangeTooLong is returned for a series range above maxSeriesDays
+var ErrRangeTooLong = errors.New("range too long, a series covers at most 366 days by day, 5 years by week and 20 years by month")
+
+// maxSeriesDays caps the days of a series per bucket, every bucket of the
+// range is listed
+var maxSeriesDays = map[string]int{BucketDay: 366, BucketWeek: 5 * 366, BucketMonth: 20 * 366}
+
+// DateLayout is the layout of the days in the parameters and the reports
+const DateLayout = "2006-01-02"
+
+// Range is the days of a report, both included
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// SpeciesCount is a species of the top species report
+type SpeciesCount struct {
+	Name      string `json:"name"`
+	Types     string `json:"types"`
+	Favorites int64  `json:"favorites"`
+}
+
+// TypeCount is a type of the type distribution. A favorite counts for each
+// of its types, so the shares may add up to more than 1.
+type TypeCount struct {
+	Type      string  `json:"type"`
+	Favorites int64   `json:"favorites"`
+	Share     float64 `json:"share"` // of the favorites of the range
+}
+
+// Point is a bucket of a series, named by its first day
+type Point struct {
+	Bucket string `json:"bucket"`
+	Count  int64  `json:"count"`
+}
+
+// rollupDays narrows a rollup to the range
+func rollupDays(db *gorm.DB, model interface{}, r Range) *gorm.DB {
+	return db.Model(model).Where("day >= ? AND day <= ?", Day(r.From), Day(r.To))
+}
+
+// TopSpecies returns the species with the most new favorites in the range
+func TopSpecies(ctx context.Context, db *gorm.DB, r Range, limit int) ([]SpeciesCount, error) {
+	top := []SpeciesCount{}
+	err := rollupDays(db.WithContext(ctx), &models.AnalyticsFavoriteDay{}, r).
+		Select("name, MAX(types) AS types, SUM(favorites) AS favorites").
+		Group("name").
+		Order("favorites DESC, name").
+		Limit(limit).
+		Scan(&top).Error
+	return top, err
+}
+
+// TypeDistribution counts the new favorites of the range by type
+func TypeDistribution(ctx context.Context, db *gorm.DB, r Range) ([]TypeCount, error) {
+	var rows []struct {
+		Types     string
+		Favorites int64
+	}
+	err := rollupDays(db.WithContext(ctx), &models.AnalyticsFavoriteDay{}, r).
+		Select("types, SUM(favorites) AS favorites").
+		Group("types").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	byType := map[string]int64{}
+	for _, row := range rows {
+		total += row.Favorites
+		for _, t := range splitTypes(row.Types) {
+			byType[t] += row.Favorites
+		}
+	}
+	counts := make([]TypeCount, 0, len(byType))
+	for t, favorites := range byType {
+		share := math.Round(float64(favorites)/float64(total)*10000) / 10000
+		counts = append(counts, TypeCount{Type: t, Favorites: favorites, Share: share})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Favorites != counts[j].Favorites {
+			return counts[i].Favorites > counts[j].Favorites
+		}
+		return counts[i].Type < counts[j].Type
+	})
+	return counts, nil
+}
+
+// NewFavorites counts the favorites created per bucket of the range
+func NewFavorites(ctx context.Context, db *gorm.DB, r Range, bucket string) ([]Point, error) {
+	if err := checkSeries(r, bucket); err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Day       time.Time
+		Favorites int64
+	}
+	err := rollupDays(db.WithContext(ctx), &models.AnalyticsUserDay{}, r).
+		Select("day, SUM(favorites) AS favorites").
+		Group("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return series(r, bucket, func(add func(day time.Time, count int64)) {
+		for _, row := range rows {
+			add(row.Day, row.Favorites)
+		}
+	})
+}
+
+// ActiveUsers counts the users who created favorites per bucket of the range
+func ActiveUsers(ctx context.Context, db *gorm.DB, r Range, bucket string) ([]Point, error) {
+	if err := checkSeries(r, bucket); err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Day       time.Time
+		UserEmail string
+	}
+	err := rollupDays(db.WithContext(ctx), &models.AnalyticsUserDay{}, r).
+		Select("day, user_email").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// a user counts once per bucket
+	seen := map[string]bool{}
+	return series(r, bucket, func(add func(day time.Time, count int64)) {
+		for _, row := range rows {
+			key := BucketStart(row.Day, bucket).Format(DateLayout) + " " + row.UserEmail
+			if !seen[key] {
+				seen[key] = true
+				add(row.Day, 1)
+			}
+		}
+	})
+}
+
+// checkSeries validates the bucket and the length of the range
+func checkSeries(r Range, bucket string) error {
+	max, ok := maxSeriesDays[bucket]
+	if !ok {
+		return ErrInvalidBucket
+	}
+	if Day(r.To).After(Day(r.From).AddDate(0, 0, max-1)) {
+		return ErrRangeTooLong
+	}
+	return nil
+}
+
+// series sums the counts per bucket, every bucket of the range is listed
+func series(r Range, bucket string, fill func(add func(day time.Time, count int64))) ([]Point, error) {
+	if err := checkSeries(r, bucket); err != nil {
+		return nil, err
+	}
+	points := []Point{}
+	index := map[string]int{}
+	for start := BucketStart(r.From, bucket); !start.After(Day(r.To)); start = nextBucket(start, bucket) {
+		index[start.Format(DateLayout)] = len(points)
+		points = append(points, Point{Bucket: start.Format(DateLayout)})
+	}
+	fill(func(day time.Time, count int64) {
+		if i, ok := index[BucketStart(day, bucket).Format(DateLayout)]; ok {
+			points[i].Count += count
+		}
+	})
+	return points, nil
+}
+
+// BucketStart is the first day of the bucket holding t
+func BucketStart(t time.Time, bucket string) time.Time {
+	day := Day(t)
+	switch bucket {
+	case BucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+func nextBucket(start time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketWeek:
+		return start.AddDate(0, 0, 7)
+	case BucketMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
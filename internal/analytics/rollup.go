@@ -0,0 +1,189 @@
+// Package analytics aggregates the favorites for the managers. A refresh
+// job rolls the favorites up per day, so the reports read a few rows per
+// day instead of scanning the favorites.
+package analytics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go-api/config"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Settings tune the refresh job
+type Settings struct {
+	Interval time.Duration // between two refreshes
+	Overlap  time.Duration // changes committed this late after the watermark are still seen
+}
+
+// LoadSettings reads the settings from the environment
+func LoadSettings() Settings {
+	return Settings{
+		Interval: config.GetEnvDuration("ANALYTICS_REFRESH_INTERVAL", 5*time.Minute), // Default refresh
+		Overlap:  config.GetEnvDuration("ANALYTICS_REFRESH_OVERLAP", 5*time.Minute),  // Default overlap
+	}
+}
+
+// the name of the favorites rollup
+const favoritesRollup = "favorites"
+
+// Start refreshes the rollups now and then every Interval until ctx is done
+func Start(ctx context.Context, db *gorm.DB, s Settings) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := Refresh(ctx, db, s); err != nil {
+			log.Printf("analytics: failed to refresh: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh recomputes the days of the favorites created, changed or deleted
+// since the last refresh, the first one computes every day. A day is
+// recomputed from its favorites, so refreshing twice is harmless.
+func Refresh(ctx context.Context, db *gorm.DB, s Settings) (models.AnalyticsRollup, error) {
+	db = db.WithContext(ctx)
+	started := time.Now()
+
+	rollup := models.AnalyticsRollup{Name: favoritesRollup}
+	err := db.First(&rollup, "name = ?", favoritesRollup).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return rollup, err
+	}
+
+	// the days of the favorites touched since the watermark, a favorite
+	// counts on the day it was created
+	changed := db.Unscoped().Model(&models.Pokemon{})
+	if !rollup.Watermark.IsZero() {
+		since := rollup.Watermark.Add(-s.Overlap)
+		changed = changed.Where("updated_at > ? OR deleted_at > ?", since, since)
+	}
+	var created []time.Time
+	if err := changed.Pluck("created_at", &created).Error; err != nil {
+		return rollup, err
+	}
+	days := map[time.Time]bool{}
+	for _, t := range created {
+		days[Day(t)] = true
+	}
+	sorted := make([]time.Time, 0, len(days))
+	for day := range days {
+		sorted = append(sorted, day)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	types, err := speciesTypes(db)
+	if err != nil {
+		return rollup, err
+	}
+	for _, day := range sorted {
+		if err := rollUpDay(db, day, types); err != nil {
+			return rollup, err
+		}
+	}
+
+	rollup.Watermark = started
+	rollup.RefreshedAt = time.Now()
+	rollup.Days = len(sorted)
+	return rollup, db.Save(&rollup).Error
+}
+
+// rollUpDay replaces the rows of a day by the counts of its favorites
+func rollUpDay(db *gorm.DB, day time.Time, types map[string]string) error {
+	var favorites []struct {
+		Name      string
+		Type      string
+		UserEmail string
+	}
+	err := db.Model(&models.Pokemon{}).
+		Select("name, type, user_email").
+		Where("created_at >= ? AND created_at < ?", day, day.AddDate(0, 0, 1)).
+		Scan(&favorites).Error
+	if err != nil {
+		return err
+	}
+
+	bySpecies := map[[2]string]int64{}
+	byUser := map[string]int64{}
+	for _, f := range favorites {
+		name := strings.ToLower(strings.TrimSpace(f.Name))
+		t, ok := types[name]
+		if !ok {
+			t = normalizeTypes(f.Type)
+		}
+		bySpecies[[2]string{name, t}]++
+		byUser[f.UserEmail]++
+	}
+	speciesRows := make([]models.AnalyticsFavoriteDay, 0, len(bySpecies))
+	for key, count := range bySpecies {
+		speciesRows = append(speciesRows, models.AnalyticsFavoriteDay{Day: day, Name: key[0], Types: key[1], Favorites: count})
+	}
+	userRows := make([]models.AnalyticsUserDay, 0, len(byUser))
+	for email, count := range byUser {
+		userRows = append(userRows, models.AnalyticsUserDay{Day: day, UserEmail: email, Favorites: count})
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day = ?", day).Delete(&models.AnalyticsFavoriteDay{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("day = ?", day).Delete(&models.AnalyticsUserDay{}).Error; err != nil {
+			return err
+		}
+		if len(speciesRows) > 0 {
+			if err := tx.CreateInBatches(speciesRows, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(userRows) > 0 {
+			return tx.CreateInBatches(userRows, 500).Error
+		}
+		return nil
+	})
+}
+
+// speciesTypes maps the species names to their types
+func speciesTypes(db *gorm.DB) (map[string]string, error) {
+	var species []models.Species
+	if err := db.Select("name, types").Find(&species).Error; err != nil {
+		return nil, err
+	}
+	types := map[string]string{}
+	for _, s := range species {
+		types[s.Name] = normalizeTypes(s.Types)
+	}
+	return types, nil
+}
+
+// normalizeTypes writes "Fire, Flying" as "fire/flying"
+func normalizeTypes(types string) string {
+	return strings.Join(splitTypes(types), "/")
+}
+
+func splitTypes(types string) []string {
+	var split []string
+	for _, t := range strings.FieldsFunc(strings.ToLower(types), func(r rune) bool { return r == '/' || r == ',' }) {
+		if t = strings.TrimSpace(t); t != "" {
+			split = append(split, t)
+		}
+	}
+	return split
+}
+
+// Day is the UTC day of t
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
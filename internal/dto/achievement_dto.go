@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+type AchievementResponse struct {
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Awarded     bool       `json:"awarded"`
+	AwardedAt   *time.Time `json:"awardedAt"`
+}
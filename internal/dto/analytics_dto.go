@@ -0,0 +1,8 @@
+package dto
+
+type AnalyticsResponse struct {
+	From   string      `json:"from"`
+	To     string      `json:"to"`
+	Bucket string      `json:"bucket,omitempty"`
+	Items  interface{} `json:"items"`
+}
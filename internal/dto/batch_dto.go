@@ -0,0 +1,33 @@
+package dto
+
+import "encoding/json"
+
+// BatchOperation is one sub-request of a batch. Path, headers and body may
+// reference earlier operations with {{<id>.id}} (the created id) or
+// {{<id>.body.<path>}} (a value of the JSON response).
+type BatchOperation struct {
+	ID      string            `json:"id" binding:"omitempty,max=50"`
+	Method  string            `json:"method" binding:"required,oneof=GET POST PUT PATCH DELETE"`
+	Path    string            `json:"path" binding:"required,startswith=/api/"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body" swaggertype:"object"`
+}
+
+type BatchRequest struct {
+	Transaction bool             `json:"transaction"`
+	Operations  []BatchOperation `json:"operations" binding:"required,min=1,max=50,dive"`
+}
+
+// BatchResult is the response of one operation
+type BatchResult struct {
+	ID      string            `json:"id,omitempty"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty" swaggertype:"object"`
+}
+
+type BatchResponse struct {
+	Transaction bool          `json:"transaction"`
+	Committed   bool          `json:"committed"`
+	Results     []BatchResult `json:"results"`
+}
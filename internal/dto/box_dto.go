@@ -0,0 +1,24 @@
+package dto
+
+type CreateBoxRequest struct {
+	Name     string `json:"name" binding:"required,max=30"`
+	Capacity int    `json:"capacity" binding:"required,min=1,max=1000"`
+}
+
+type UpdateBoxRequest struct {
+	Name     string `json:"name" binding:"omitempty,max=30"`
+	Capacity int    `json:"capacity" binding:"omitempty,min=1,max=1000"`
+}
+
+type TransferBoxPokemonsRequest struct {
+	PokemonIDs []uint `json:"pokemonIds" binding:"required,min=1"`
+	FromBoxID  uint   `json:"fromBoxId"`
+	ToBoxID    uint   `json:"toBoxId" binding:"required"`
+}
+
+type BoxResponse struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+	Count    int64  `json:"count"`
+}
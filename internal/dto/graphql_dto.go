@@ -0,0 +1,8 @@
+package dto
+
+// GraphQLRequest is the body of a GraphQL query or mutation
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+// Import row statuses
+const (
+	ImportRowValid   = "valid"
+	ImportRowCreated = "created"
+	ImportRowInvalid = "invalid"
+	ImportRowFailed  = "failed"
+	ImportRowSkipped = "skipped"
+)
+
+// ImportRowResult reports what happened to one row of an import
+type ImportRowResult struct {
+	Row    int      `json:"row"` // 1 based, the CSV header is not counted
+	Status string   `json:"status"`
+	ID     uint     `json:"id,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Mode    string            `json:"mode"`
+	Total   int               `json:"total"`
+	Valid   int               `json:"valid"`
+	Invalid int               `json:"invalid"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// LogSummary is a log entry without its bodies, as listed by the explorer
+type LogSummary struct {
+	ID         uint      `json:"id"`
+	RequestID  string    `json:"request_id"`
+	UserEmail  string    `json:"user_email"`
+	Method     string    `json:"method"`
+	URI        string    `json:"uri"`
+	Route      string    `json:"route"`
+	ClientIP   string    `json:"client_ip"`
+	StatusCode int       `json:"status_code"`
+	DurationMs float64   `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
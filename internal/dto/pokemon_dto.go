@@ -5,11 +5,25 @@ type CreateFavoritePokemonRequest struct {
 	Type      string `json:"type"`
 	Notes     string `json:"notes" binding:"required,max=30"`
 	Sprite    string `json:"sprite"`
+	Shiny     bool   `json:"shiny"`
+	Form      string `json:"form" binding:"omitempty,oneof=alolan galarian hisuian paldean gigantamax mega"`
+	Gender    string `json:"gender" binding:"omitempty,oneof=male female genderless"`
 	UserEmail string `json:"userEmail" binding:"required,email"`
+	Tags      []string `json:"tags" binding:"omitempty,dive,min=1,max=20"`
 }
 
 type UpdateFavoritePokemonRequest struct {
 	Name     string `json:"name"`
 	Type     string `json:"type"`
 	Notes    string `json:"notes" binding:"required,max=30"`
+	Shiny    bool   `json:"shiny"`
+	Form     string `json:"form" binding:"omitempty,oneof=alolan galarian hisuian paldean gigantamax mega"`
+	Gender   string `json:"gender" binding:"omitempty,oneof=male female genderless"`
+}
+
+// OwnerResponse is the owner embedded with include=owner
+type OwnerResponse struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
 }
@@ -0,0 +1,11 @@
+package dto
+
+import (
+	"go-api/internal/search"
+	"go-api/internal/utils"
+)
+
+type SearchResponse struct {
+	utils.DataResponse
+	Facets []search.Facet `json:"facets"`
+}
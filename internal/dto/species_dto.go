@@ -0,0 +1,11 @@
+package dto
+
+type CompletionResponse struct {
+	TotalSpecies int64            `json:"totalSpecies"`
+	OwnedSpecies int64            `json:"ownedSpecies"`
+	Completion   float64          `json:"completion"`
+	Favorites    int64            `json:"favorites"`
+	Shiny        int64            `json:"shiny"`
+	Forms        map[string]int64 `json:"forms"`
+	Genders      map[string]int64 `json:"genders"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type SetPokemonTagsRequest struct {
+	Tags []string `json:"tags" binding:"dive,min=1,max=20"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+type UsageResponse struct {
+	Since  time.Time   `json:"since"`
+	Window string      `json:"window"`
+	Items  interface{} `json:"items"`
+}
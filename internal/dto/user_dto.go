@@ -12,3 +12,11 @@ type UpdateUserRequest struct {
 	Email string `json:"email" binding:"omitempty,email"`
 	Role  string `json:"role" binding:"omitempty,oneof=admin user manager"`
 }
+
+// PatchUserDocument is the patchable representation of a user, the patched
+// result must still be a complete user
+type PatchUserDocument struct {
+	Name  string `json:"name" binding:"required,min=3"`
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=admin user manager"`
+}
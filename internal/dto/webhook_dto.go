@@ -0,0 +1,16 @@
+package dto
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Description string   `json:"description" binding:"max=200"`
+	EventTypes  []string `json:"eventTypes" binding:"required,min=1,dive,required"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=100"`
+}
+
+type UpdateWebhookRequest struct {
+	URL          string   `json:"url" binding:"omitempty,url,max=500"`
+	Description  *string  `json:"description" binding:"omitempty,max=200"`
+	EventTypes   []string `json:"eventTypes" binding:"omitempty,min=1,dive,required"`
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotateSecret"`
+}
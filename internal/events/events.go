@@ -0,0 +1,108 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event types emitted by the handlers
+const (
+	PokemonCreated  = "pokemon.created"
+	PokemonUpdated  = "pokemon.updated"
+	PokemonDeleted  = "pokemon.deleted"
+	UserRegistered  = "user.registered"
+	UserCreated     = "user.created"
+	UserUpdated     = "user.updated"
+	UserDeleted     = "user.deleted"
+	UserRoleChanged = "user.role_changed"
+	BoxCreated      = "box.created"
+	BoxUpdated      = "box.updated"
+	BoxDeleted      = "box.deleted"
+)
+
+// Types lists every event type, for subscribers that want them all
+var Types = []string{
+	PokemonCreated, PokemonUpdated, PokemonDeleted,
+	UserRegistered, UserCreated, UserUpdated, UserDeleted, UserRoleChanged,
+	BoxCreated, BoxUpdated, BoxDeleted,
+}
+
+// Event is a domain event emitted after an entity change
+type Event struct {
+	Type       string      `json:"type"`
+	UserEmail  string      `json:"userEmail"`
+	Payload    interface{} `json:"payload"`
+	OccurredAt time.Time   `json:"occurredAt"`
+}
+
+// Handler consumes a domain event, an error has the event handed to it
+// again later
+type Handler func(Event) error
+
+// Subscription is a named handler of some event types. A shared one acts
+// once for every instance, e.g. by writing to the database. A local one
+// keeps state in its instance, e.g. the change feed, so every instance must
+// hand it every event.
+type Subscription struct {
+	Name   string
+	Local  bool
+	Handle Handler
+	types  map[string]bool
+}
+
+// Wants reports whether the subscription handles the event type
+func (s Subscription) Wants(eventType string) bool {
+	return s.types[eventType]
+}
+
+var (
+	mu            sync.RWMutex
+	subscriptions []Subscription
+)
+
+// Subscribe registers a shared handler for the given event types
+func Subscribe(name string, h Handler, eventTypes ...string) {
+	subscribe(Subscription{Name: name, Handle: h}, eventTypes)
+}
+
+// SubscribeLocal registers a local handler for the given event types
+func SubscribeLocal(name string, h Handler, eventTypes ...string) {
+	subscribe(Subscription{Name: name, Local: true, Handle: h}, eventTypes)
+}
+
+func subscribe(s Subscription, eventTypes []string) {
+	s.types = map[string]bool{}
+	for _, eventType := range eventTypes {
+		s.types[eventType] = true
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	subscriptions = append(subscriptions, s)
+}
+
+// Subscriptions returns the registered subscriptions
+func Subscriptions() []Subscription {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Subscription(nil), subscriptions...)
+}
+
+// Publish dispatches the event to every subscribed handler and returns
+// their errors
+func Publish(e Event) error {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	var errs []error
+	for _, s := range Subscriptions() {
+		if !s.Wants(e.Type) {
+			continue
+		}
+		if err := s.Handle(e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
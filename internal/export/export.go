@@ -0,0 +1,117 @@
+package export
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"mime"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Format is an export file format
+type Format string
+
+// Supported formats
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned when no supported format is acceptable
+var ErrUnsupportedFormat = errors.New("format must be csv, ndjson or xlsx")
+
+var contentTypes = map[Format]string{
+	CSV:    "text/csv; charset=utf-8",
+	NDJSON: "application/x-ndjson",
+	XLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func init() {
+	// the blob store serves exports with the type of their extension
+	mime.AddExtensionType(".ndjson", contentTypes[NDJSON])
+	mime.AddExtensionType(".xlsx", contentTypes[XLSX])
+}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Negotiate picks the format from the format parameter or, without one,
+// from the Accept header. CSV is the default.
+func Negotiate(format, accept string) (Format, error) {
+	if format != "" {
+		f := Format(strings.ToLower(format))
+		if _, ok := contentTypes[f]; !ok {
+			return "", ErrUnsupportedFormat
+		}
+		return f, nil
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return CSV, nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv", "*/*", "text/*":
+			return CSV, nil
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return NDJSON, nil
+		case contentTypes[XLSX]:
+			return XLSX, nil
+		}
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Table describes the columns of an export and how a row is read
+type Table struct {
+	Columns []string
+	Scan    func(db *gorm.DB, rows *sql.Rows) ([]interface{}, error)
+}
+
+// Stream writes every row of the query to out, rows are read one at a time
+// from the database cursor so memory stays constant
+func Stream(db *gorm.DB, table Table, format Format, out io.Writer) (int64, error) {
+	rows, err := db.Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	writer, err := NewWriter(format, out)
+	if err != nil {
+		return 0, err
+	}
+	if err := writer.WriteHeader(table.Columns); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for rows.Next() {
+		values, err := table.Scan(db, rows)
+		if err != nil {
+			return count, err
+		}
+		if err := writer.WriteRow(values); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	return count, writer.Close()
+}
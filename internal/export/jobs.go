@@ -0,0 +1,93 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"go-api/internal/models"
+	"go-api/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// Source builds the filtered query and the table of a resource
+type Source func(db *gorm.DB, resource string, query url.Values) (*gorm.DB, Table, error)
+
+// workers caps the exports running in the background at once
+var workers = make(chan struct{}, 2)
+
+// Enqueue saves the job and runs it in the background
+func Enqueue(db *gorm.DB, job *models.ExportJob, source Source) error {
+	job.Status = models.ExportPending
+	if err := db.Create(job).Error; err != nil {
+		return err
+	}
+	go run(db, *job, source)
+	return nil
+}
+
+// Resume restarts the jobs a previous shutdown interrupted
+func Resume(db *gorm.DB, source Source) {
+	var jobs []models.ExportJob
+	db.Where("status IN ?", []string{models.ExportPending, models.ExportRunning}).Find(&jobs)
+	for _, job := range jobs {
+		go run(db, job, source)
+	}
+}
+
+// Key returns the blob store key of a job result
+func Key(job models.ExportJob) string {
+	return fmt.Sprintf("exports/%d-%s%s", job.ID, job.Resource, Format(job.Format).Extension())
+}
+
+func run(db *gorm.DB, job models.ExportJob, source Source) {
+	workers <- struct{}{}
+	defer func() { <-workers }()
+
+	db.Model(&job).Update("status", models.ExportRunning)
+	rows, err := write(db, job, source)
+
+	now := time.Now()
+	updates := map[string]interface{}{"rows": rows, "finished_at": &now}
+	if err != nil {
+		log.Printf("export job %d failed: %v", job.ID, err)
+		updates["status"] = models.ExportFailed
+		updates["error"] = err.Error()
+	} else {
+		updates["status"] = models.ExportDone
+		updates["key"] = Key(job)
+	}
+	db.Model(&job).Updates(updates)
+}
+
+// write streams the export to a temporary file and uploads it
+func write(db *gorm.DB, job models.ExportJob, source Source) (int64, error) {
+	query, err := url.ParseQuery(job.Query)
+	if err != nil {
+		return 0, err
+	}
+	rows, table, err := source(db, job.Resource, query)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	format := Format(job.Format)
+	count, err := Stream(rows, table, format, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return count, err
+	}
+	return count, storage.PutFile(context.Background(), storage.Blobs, Key(job), file.Name(), format.ContentType())
+}
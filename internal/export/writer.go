@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Writer encodes rows in an export format
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []interface{}) error
+	// Close flushes the output, it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter returns a writer for the format
+func NewWriter(format Format, out io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(out)}, nil
+	case NDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(out)}, nil
+	case XLSX:
+		return newXLSXWriter(out), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// flushEvery is the number of rows buffered before flushing to the client
+const flushEvery = 500
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func (w *csvWriter) WriteHeader(columns []string) error {
+	return w.w.Write(columns)
+}
+
+func (w *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(value)
+		if _, ok := value.(string); ok {
+			record[i] = escapeFormula(record[i])
+		}
+	}
+	if err := w.w.Write(record); err != nil {
+		return err
+	}
+	if w.rows++; w.rows%flushEvery == 0 {
+		w.w.Flush()
+	}
+	return w.w.Error()
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func (w *ndjsonWriter) WriteHeader(columns []string) error {
+	w.columns = columns
+	return nil
+}
+
+// WriteRow writes the row as an object keeping the column order
+func (w *ndjsonWriter) WriteRow(values []interface{}) error {
+	w.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			w.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(w.columns[i])
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		w.w.Write(key)
+		w.w.WriteByte(':')
+		w.w.Write(encoded)
+	}
+	_, err := w.w.WriteString("}\n")
+	return err
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.w.Flush()
+}
+
+// formatValue renders a value as text
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// escapeFormula keeps spreadsheet applications from evaluating user text
+func escapeFormula(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api/internal/events"
+)
+
+// Message is an event of the feed with the id clients resume from
+type Message struct {
+	ID    string
+	Event events.Event
+}
+
+// Filter decides which events a subscription receives
+type Filter func(events.Event) bool
+
+// Hub fans the domain events out to the subscriptions and keeps the last
+// ones for the clients resuming with Last-Event-ID
+type Hub struct {
+	mu            sync.Mutex
+	epoch         string // ids of another process never resume
+	seq           uint64
+	replay        []Message
+	size          int
+	subscriptions map[*Subscription]struct{}
+}
+
+// Subscription receives the messages matching its filter on C. C is closed
+// when the subscription falls behind or is closed, the client then resumes
+// from the last id it got.
+type Subscription struct {
+	C      chan Message
+	filter Filter
+	hub    *Hub
+}
+
+// subscriptionBuffer is the number of messages a slow client may lag behind
+const subscriptionBuffer = 64
+
+// Default is the hub fed by Register
+var Default = NewHub(1000)
+
+// NewHub returns a hub replaying up to size messages
+func NewHub(size int) *Hub {
+	if size <= 0 {
+		size = 1000
+	}
+	return &Hub{
+		epoch:         strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:          size,
+		subscriptions: map[*Subscription]struct{}{},
+	}
+}
+
+// Register feeds the Default hub, replaying up to size messages, from the
+// domain events
+func Register(size int) {
+	Default = NewHub(size)
+	hub := Default
+	events.SubscribeLocal("feed", func(e events.Event) error {
+		hub.Publish(e)
+		return nil
+	}, events.Types...)
+}
+
+// Publish numbers the event, stores it for replay and sends it to the
+// matching subscriptions without waiting for them
+func (h *Hub) Publish(e events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	message := Message{ID: fmt.Sprintf("%s-%d", h.epoch, h.seq), Event: e}
+	h.replay = append(h.replay, message)
+	if len(h.replay) > h.size {
+		h.replay = h.replay[len(h.replay)-h.size:]
+	}
+
+	for s := range h.subscriptions {
+		if !s.filter(e) {
+			continue
+		}
+		select {
+		case s.C <- message:
+		default:
+			// a client that does not keep up reconnects and resumes
+			h.remove(s)
+		}
+	}
+}
+
+// Subscribe registers a subscription and returns the messages published
+// after lastEventID. complete is false when lastEventID is unknown or older
+// than the replay buffer, the client missed events and must refetch.
+func (h *Hub) Subscribe(lastEventID string, filter Filter) (s *Subscription, replay []Message, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s = &Subscription{C: make(chan Message, subscriptionBuffer), filter: filter, hub: h}
+	h.subscriptions[s] = struct{}{}
+
+	if lastEventID == "" {
+		return s, nil, true
+	}
+	seq, ok := h.parseID(lastEventID)
+	if !ok || seq > h.seq {
+		return s, nil, false
+	}
+	oldest := h.seq - uint64(len(h.replay)) + 1
+	if seq+1 < oldest {
+		return s, nil, false
+	}
+	for _, message := range h.replay[len(h.replay)-int(h.seq-seq):] {
+		if filter(message.Event) {
+			replay = append(replay, message)
+		}
+	}
+	return s, replay, true
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+func (h *Hub) remove(s *Subscription) {
+	if _, ok := h.subscriptions[s]; ok {
+		delete(h.subscriptions, s)
+		close(s.C)
+	}
+}
+
+func (h *Hub) parseID(id string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(id, "-")
+	if !found || epoch != h.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
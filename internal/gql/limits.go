@@ -0,0 +1,184 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Limits bounds the depth and the complexity of a query before it runs
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+	MaxLimit      int // larger limits are cut to it by the resolvers, 0 for none
+}
+
+// Check measures the operation of the query against the schema. Every field
+// costs one, the selection of a field taking a limit argument costs limit
+// times, so listing 50 favorites with their owner costs about 100.
+// A limit bound to a variable is read from the variables, or from the
+// default of the variable. Introspection fields are free and syntax errors
+// are left to the executor.
+func (l Limits) Check(schema *graphql.Schema, query, operationName string, variables map[string]interface{}) error {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+
+	m := measure{fragments: map[string]*ast.FragmentDefinition{}, variables: map[string]interface{}{}, visiting: map[string]bool{}, maxLimit: l.MaxLimit}
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			m.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operation == nil && (operationName == "" || (d.Name != nil && d.Name.Value == operationName)) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	// the executor falls back to the defaults of the missing variables
+	for _, definition := range operation.VariableDefinitions {
+		if definition.DefaultValue != nil {
+			m.variables[definition.Variable.Name.Value] = definition.DefaultValue.GetValue()
+		}
+	}
+	for name, value := range variables {
+		m.variables[name] = value
+	}
+
+	root := schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	depth, complexity := m.selectionSet(root, operation.SelectionSet)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity)
+	}
+	return nil
+}
+
+type measure struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool // fragments being measured, guards against cycles
+	maxLimit  int
+}
+
+// selectionSet returns the depth and the complexity of a selection on parent
+func (m *measure) selectionSet(parent *graphql.Object, set *ast.SelectionSet) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			d, c = m.field(parent, s)
+		case *ast.InlineFragment:
+			d, c = m.selectionSet(parent, s.SelectionSet)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := m.fragments[name]
+			if !ok || m.visiting[name] {
+				continue
+			}
+			m.visiting[name] = true
+			d, c = m.selectionSet(parent, fragment.SelectionSet)
+			delete(m.visiting, name)
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (m *measure) field(parent *graphql.Object, field *ast.Field) (int, int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+
+	var child *graphql.Object
+	multiplier := 1
+	if parent != nil {
+		if definition, ok := parent.Fields()[field.Name.Value]; ok {
+			child = objectOf(definition.Type)
+			multiplier = m.limit(definition, field)
+		}
+	}
+	depth, complexity := m.selectionSet(child, field.SelectionSet)
+	return depth + 1, 1 + multiplier*complexity
+}
+
+// limit reads the limit argument of a field, or its default value
+func (m *measure) limit(definition *graphql.FieldDefinition, field *ast.Field) int {
+	for _, arg := range definition.Args {
+		if arg.Name() != "limit" {
+			continue
+		}
+		value := arg.DefaultValue
+		for _, given := range field.Arguments {
+			if given.Name.Value != "limit" {
+				continue
+			}
+			switch v := given.Value.(type) {
+			case *ast.IntValue:
+				value = v.Value
+			case *ast.Variable:
+				value = m.variables[v.Name.Value]
+			}
+		}
+		// a null or invalid limit runs with the default
+		n := toInt(value)
+		if n <= 0 {
+			n = toInt(arg.DefaultValue)
+		}
+		if m.maxLimit > 0 && n > m.maxLimit {
+			return m.maxLimit
+		}
+		if n > 0 {
+			return n
+		}
+	}
+	return 1
+}
+
+func objectOf(t graphql.Type) *graphql.Object {
+	for {
+		switch v := t.(type) {
+		case *graphql.NonNull:
+			t = v.OfType
+		case *graphql.List:
+			t = v.OfType
+		case *graphql.Object:
+			return v
+		default:
+			return nil
+		}
+	}
+}
+
+func toInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package gql
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func testSchema(t *testing.T) *graphql.Schema {
+	t.Helper()
+	item := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Item",
+		Fields: graphql.Fields{"id": {Type: graphql.Int}, "name": {Type: graphql.String}},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"items": {
+					Type: graphql.NewList(item),
+					Args: graphql.FieldConfigArgument{"limit": {Type: graphql.Int, DefaultValue: 10}},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &schema
+}
+
+func TestCheckComplexity(t *testing.T) {
+	schema := testSchema(t)
+	limits := Limits{MaxDepth: 5, MaxComplexity: 100, MaxLimit: 1000}
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		ok        bool
+	}{
+		{"default limit", `{ items { id name } }`, nil, true},
+		{"literal limit", `{ items(limit: 60) { id name } }`, nil, false},
+		{"variable", `query($l: Int) { items(limit: $l) { id name } }`, map[string]interface{}{"l": float64(60)}, false},
+		{"variable default", `query($l: Int = 100000) { items(limit: $l) { id name } }`, nil, false},
+		{"variable overrides its default", `query($l: Int = 100000) { items(limit: $l) { id name } }`, map[string]interface{}{"l": float64(5)}, true},
+		{"null variable", `query($l: Int) { items(limit: $l) { id name } }`, map[string]interface{}{"l": nil}, true},
+		{"fragment", `{ items(limit: 60) { ...f } } fragment f on Item { id name }`, nil, false},
+		{"introspection", `{ __schema { types { name } } }`, nil, true},
+	}
+	for _, tt := range tests {
+		err := limits.Check(schema, tt.query, "", tt.variables)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Check = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckCutsLimitsToMaxLimit(t *testing.T) {
+	schema := testSchema(t)
+	limits := Limits{MaxComplexity: 300, MaxLimit: 100}
+	if err := limits.Check(schema, `{ items(limit: 100000) { id name } }`, "", nil); err != nil {
+		t.Errorf("limit above MaxLimit counted in full: %v", err)
+	}
+}
+
+func TestCheckDepth(t *testing.T) {
+	schema := testSchema(t)
+	err := Limits{MaxDepth: 1}.Check(schema, `{ items { id } }`, "", nil)
+	if err == nil || !strings.Contains(err.Error(), "depth") {
+		t.Errorf("Check = %v, want a depth error", err)
+	}
+}
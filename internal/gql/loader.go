@@ -0,0 +1,76 @@
+package gql
+
+import "sync"
+
+// Loader batches the keys requested by the resolvers of one query level and
+// fetches them with a single call when the first value is needed. The
+// executor resolves thunks breadth first, so every sibling is queued before
+// any of them is read. A loader lives for one request.
+type Loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	values  map[K]V
+	errs    map[K]error
+}
+
+// NewLoader builds a loader around fetch, keys missing from the returned map
+// resolve to null
+func NewLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:  fetch,
+		queued: map[K]bool{},
+		values: map[K]V{},
+		errs:   map[K]error{},
+	}
+}
+
+// Load queues key and returns a thunk a resolver can return as its value
+func (l *Loader[K, V]) Load(key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		value, ok, err := l.Get(key)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// Get returns the value of key, fetching every queued key first
+func (l *Loader[K, V]) Get(key K) (V, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	if len(l.pending) > 0 {
+		keys := l.pending
+		l.pending = nil
+		values, err := l.fetch(keys)
+		for _, k := range keys {
+			if err != nil {
+				l.errs[k] = err
+			} else if value, ok := values[k]; ok {
+				l.values[k] = value
+			}
+		}
+	}
+
+	if err := l.errs[key]; err != nil {
+		var zero V
+		return zero, false, err
+	}
+	value, ok := l.values[key]
+	return value, ok, nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"go-api/internal/achievements"
+	"go-api/internal/dto"
+	"go-api/internal/models"
+	"go-api/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Get my achievements
+// GetMyAchievements godoc
+// @Summary      Get my achievements
+// @Description  Get every achievement with the badges awarded to the logged in user
+// @Tags         Achievements
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to fetch achievements"
+// @Router 		 /me/achievements [get]
+func GetMyAchievements(c *gin.Context) {
+	email := c.GetString("email")
+
+	// fetch awarded badges
+	var awarded []models.UserAchievement
+	if err := utils.DB(c).Where("user_email = ?", email).Find(&awarded).Error; err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to fetch achievements", nil)
+		return
+	}
+	awardedByCode := map[string]models.UserAchievement{}
+	for _, a := range awarded {
+		awardedByCode[a.Code] = a
+	}
+
+	// build the reponse
+	var dataResponse []dto.AchievementResponse
+	for _, rule := range achievements.Rules() {
+		item := dto.AchievementResponse{
+			Code:        rule.Code,
+			Name:        rule.Name,
+			Description: rule.Description,
+		}
+		if a, ok := awardedByCode[rule.Code]; ok {
+			awardedAt := a.AwardedAt
+			item.Awarded = true
+			item.AwardedAt = &awardedAt
+		}
+		dataResponse = append(dataResponse, item)
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching achievements data", dataResponse)
+}
+
+// Backfill achievements
+// BackfillAchievements godoc
+// @Summary      Backfill achievements
+// @Description  Evaluate the achievement rules against existing favorites of every user
+// @Tags         Achievements
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to backfill achievements"
+// @Router 		 /achievements/backfill [post]
+func BackfillAchievements(c *gin.Context) {
+	awarded, err := achievements.Backfill(utils.DB(c))
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to backfill achievements", gin.H{"awarded": awarded})
+		return
+	}
+
+	utils.Response(c, http.StatusOK, true, "Achievements backfilled", gin.H{"awarded": awarded})
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-api/internal/analytics"
+	"go-api/internal/dto"
+	"go-api/internal/export"
+	"go-api/internal/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Analytics routes
+// GetTopSpecies godoc
+// @Summary      Get the most favorited species
+// @Description  Rank the species by the favorites created in the range and still kept
+// @Tags         Analytics
+// @Accept       json
+// @Produce      json
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        from    query     string  false  "First day, YYYY-MM-DD (default 29 days before to)"
+// @Param        to      query     string  false  "Last day, YYYY-MM-DD (default today)"
+// @Param        limit   query     int     false  "Number of species (default 10, max 100)"
+// @Param        format  query     string  false  "json or csv, defaults to the Accept header"
+// @Success      200    {object}  utils.BaseResponse{data=dto.AnalyticsResponse}
+// @Failure      400    {object}  utils.BaseResponse  "Invalid parameter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to compute analytics"
+// @Router 		 /analytics/top-species [get]
+func GetTopSpecies(c *gin.Context) {
+	r, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	top, err := analytics.TopSpecies(c.Request.Context(), utils.DB(c), r, limit)
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to compute analytics", nil)
+		return
+	}
+
+	rows := make([][]interface{}, len(top))
+	for i, s := range top {
+		rows[i] = []interface{}{s.Name, s.Types, s.Favorites}
+	}
+	analyticsResponse(c, "top-species", r, "", top, []string{"name", "types", "favorites"}, rows)
+}
+
+// GetTypeDistribution godoc
+// @Summary      Get the favorites by type
+// @Description  Count the favorites created in the range by type. A favorite counts for each of its types, the share is of the favorites of the range
+// @Tags         Analytics
+// @Accept       json
+// @Produce      json
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        from    query     string  false  "First day, YYYY-MM-DD (default 29 days before to)"
+// @Param        to      query     string  false  "Last day, YYYY-MM-DD (default today)"
+// @Param        format  query     string  false  "json or csv, defaults to the Accept header"
+// @Success      200    {object}  utils.BaseResponse{data=dto.AnalyticsResponse}
+// @Failure      400    {object}  utils.BaseResponse  "Invalid parameter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to compute analytics"
+// @Router 		 /analytics/types [get]
+func GetTypeDistribution(c *gin.Context) {
+	r, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+
+	counts, err := analytics.TypeDistribution(c.Request.Context(), utils.DB(c), r)
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to compute analytics", nil)
+		return
+	}
+
+	rows := make([][]interface{}, len(counts))
+	for i, t := range counts {
+		rows[i] = []interface{}{t.Type, t.Favorites, t.Share}
+	}
+	analyticsResponse(c, "types", r, "", counts, []string{"type", "favorites", "share"}, rows)
+}
+
+// GetFavoritesOverTime godoc
+// @Summary      Get the new favorites over time
+// @Description  Count the favorites created per day, week (from monday) or month of the range, every bucket is listed. The range covers at most 366 days by day, 5 years by week and 20 years by month
+// @Tags         Analytics
+// @Accept       json
+// @Produce      json
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        bucket  query     string  false  "day, week or month (default day)"
+// @Param        from    query     string  false  "First day, YYYY-MM-DD (default 29 days before to)"
+// @Param        to      query     string  false  "Last day, YYYY-MM-DD (default today)"
+// @Param        format  query     string  false  "json or csv, defaults to the Accept header"
+// @Success      200    {object}  utils.BaseResponse{data=dto.AnalyticsResponse}
+// @Failure      400    {object}  utils.BaseResponse  "Invalid parameter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to compute analytics"
+// @Router 		 /analytics/favorites [get]
+func GetFavoritesOverTime(c *gin.Context) {
+	analyticsSeries(c, "favorites", analytics.NewFavorites)
+}
+
+// GetActiveUsers godoc
+// @Summary      Get the active users over time
+// @Description  Count the users who created favorites per day, week (from monday) or month of the range, every bucket is listed. The range covers at most 366 days by day, 5 years by week and 20 years by month
+// @Tags         Analytics
+// @Accept       json
+// @Produce      json
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        bucket  query     string  false  "day, week or month (default day)"
+// @Param        from    query     string  false  "First day, YYYY-MM-DD (default 29 days before to)"
+// @Param        to      query     string  false  "Last day, YYYY-MM-DD (default today)"
+// @Param        format  query     string  false  "json or csv, defaults to the Accept header"
+// @Success      200    {object}  utils.BaseResponse{data=dto.AnalyticsResponse}
+// @Failure      400    {object}  utils.BaseResponse  "Invalid parameter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to compute analytics"
+// @Router 		 /analytics/active-users [get]
+func GetActiveUsers(c *gin.Context) {
+	analyticsSeries(c, "active-users", analytics.ActiveUsers)
+}
+
+// RefreshAnalytics godoc
+// @Summary      Refresh analytics
+// @Description  Roll up the favorites changed since the last refresh now instead of waiting for the background job
+// @Tags         Analytics
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to refresh analytics"
+// @Router 		 /analytics/refresh [post]
+func RefreshAnalytics(c *gin.Context) {
+	rollup, err := analytics.Refresh(c.Request.Context(), utils.DB(c), analytics.LoadSettings())
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to refresh analytics", nil)
+		return
+	}
+
+	utils.Response(c, http.StatusOK, true, "Analytics refreshed", rollup)
+}
+
+// analyticsSeries answers a report counted per bucket
+func analyticsSeries(c *gin.Context, name string, report func(context.Context, *gorm.DB, analytics.Range, string) ([]analytics.Point, error)) {
+	r, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	bucket := c.DefaultQuery("bucket", analytics.BucketDay)
+
+	points, err := report(c.Request.Context(), utils.DB(c), r, bucket)
+	if errors.Is(err, analytics.ErrInvalidBucket) || errors.Is(err, analytics.ErrRangeTooLong) {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to compute analytics", nil)
+		return
+	}
+
+	rows := make([][]interface{}, len(points))
+	for i, p := range points {
+		rows[i] = []interface{}{p.Bucket, p.Count}
+	}
+	analyticsResponse(c, name, r, bucket, points, []string{"bucket", "count"}, rows)
+}
+
+// analyticsRange reads the from and to days, by default the last 30 days
+func analyticsRange(c *gin.Context) (analytics.Range, bool) {
+	r := analytics.Range{To: analytics.Day(time.Now())}
+	var err error
+	if to := c.Query("to"); to != "" {
+		if r.To, err = time.Parse(analytics.DateLayout, to); err != nil {
+			utils.Response(c, http.StatusBadRequest, false, "to must be a YYYY-MM-DD date", nil)
+			return r, false
+		}
+	}
+	r.From = r.To.AddDate(0, 0, -29)
+	if from := c.Query("from"); from != "" {
+		if r.From, err = time.Parse(analytics.DateLayout, from); err != nil {
+			utils.Response(c, http.StatusBadRequest, false, "from must be a YYYY-MM-DD date", nil)
+			return r, false
+		}
+	}
+	if r.From.After(r.To) {
+		utils.Response(c, http.StatusBadRequest, false, "from must not be after to", nil)
+		return r, false
+	}
+	return r, true
+}
+
+// analyticsResponse answers the report as CSV when format=csv or the Accept
+// header asks for it, as JSON otherwise
+func analyticsResponse(c *gin.Context, name string, r analytics.Range, bucket string, items interface{}, columns []string, rows [][]interface{}) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" && strings.Contains(c.GetHeader("Accept"), "text/csv") {
+		format = "csv"
+	}
+
+	switch format {
+	case "", "json":
+		// build the reponse
+		dataResponse := dto.AnalyticsResponse{
+			From:   r.From.Format(analytics.DateLayout),
+			To:     r.To.Format(analytics.DateLayout),
+			Bucket: bucket,
+			Items:  items,
+		}
+		utils.Response(c, http.StatusOK, true, "Succes fetching analytics", dataResponse)
+	case "csv":
+		filename := fmt.Sprintf("%s-%s-%s.csv", name, r.From.Format("20060102"), r.To.Format("20060102"))
+		c.Header("Content-Type", export.CSV.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		w, _ := export.NewWriter(export.CSV, c.Writer)
+		w.WriteHeader(columns)
+		for _, row := range rows {
+			if err := w.WriteRow(row); err != nil {
+				log.Printf("analytics %s failed: %v", name, err)
+				return
+			}
+		}
+		w.Close()
+	default:
+		utils.Response(c, http.StatusBadRequest, false, "format must be json or csv", nil)
+	}
+}
@@ -1,15 +1,17 @@
 package handlers
 
 import (
-	"go-api/config"
 	"go-api/internal/auth"
 	"go-api/internal/dto"
+	"go-api/internal/events"
 	"go-api/internal/models"
+	"go-api/internal/outbox"
 	"go-api/internal/utils"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // LoginHandler authenticates users and generates JWT
@@ -38,7 +40,7 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Where("email = ?", loginRequest.Email).First(&user).Error; err != nil {
+	if err := utils.DB(c).Where("email = ?", loginRequest.Email).First(&user).Error; err != nil {
 		utils.Response(c, http.StatusUnauthorized, false, "Invalid email", nil)
 		return
 	}
@@ -101,7 +103,13 @@ func RegisterHandler(c *gin.Context) {
 	}
 
 	// save the user
-	if err := config.DB.Create(&user).Error; err != nil {
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, outbox.User, user.ID, events.Event{Type: events.UserRegistered, UserEmail: user.Email, Payload: user})
+	})
+	if err != nil {
 		utils.Response(c, http.StatusConflict, false, "User email already used", nil)
 		return
 	}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-api/internal/dto"
+	"go-api/internal/outbox"
+	"go-api/internal/utils"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// batchReference matches {{<operation id>.<field path>}}
+var batchReference = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\.([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// batchQuotedReference matches a reference that is a whole JSON string
+var batchQuotedReference = regexp.MustCompile(`"` + batchReference.String() + `"`)
+
+// batchResultHeaders are the response headers copied into a result
+var batchResultHeaders = []string{"Location", "ETag", "Content-Type"}
+
+// batchStreamingRoutes answer with a stream or a file, the batch buffers
+// each response until it is complete so they cannot run in one
+var batchStreamingRoutes = []string{"/api/v1/feed", "/api/v1/export/pokemons", "/api/v1/export/users", "/api/v1/files", "/api/v1/sprites/proxy"}
+
+// inBatchKey marks the context of a sub-request, whatever path reached the
+// batch route
+type inBatchKey struct{}
+
+// Batch routes
+// Batch godoc
+// @Summary      Run a batch of requests
+// @Description  Run up to 50 sub-requests in order through the regular routes, with the caller's token. With transaction=true every write runs in one transaction that is rolled back when an operation fails. Operations can reference earlier ones with {{<id>.id}} or {{<id>.body.data.name}}. Streaming, file and export routes are refused.
+// @Tags         Batch
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        batch body dto.BatchRequest true "Operations"
+// @Success      200 {object} dto.BatchResponse
+// @Failure      400 {object} utils.BaseResponse "Validation error"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      409 {object} dto.BatchResponse "Transaction rolled back"
+// @Router       /batch [post]
+func Batch(router http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Context().Value(inBatchKey{}) != nil {
+			utils.Response(c, http.StatusBadRequest, false, "Batches cannot be nested", nil)
+			return
+		}
+
+		// get the serializer and validate it
+		var req dto.BatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ValidationErrorResponse(c, err)
+			return
+		}
+
+		// writes of a transactional batch share one transaction, their
+		// events are in the outbox of the same transaction
+		ctx := c.Request.Context()
+		tx := utils.DB(c)
+		if req.Transaction {
+			tx = tx.WithContext(ctx).Begin()
+			if tx.Error != nil {
+				utils.Response(c, http.StatusInternalServerError, false, "Failed to start transaction", nil)
+				return
+			}
+			ctx = utils.WithTx(ctx, tx)
+		}
+
+		response := dto.BatchResponse{Transaction: req.Transaction, Results: make([]dto.BatchResult, 0, len(req.Operations))}
+		done := map[string]dto.BatchResult{}
+		failed := false
+		for _, op := range req.Operations {
+			if failed && req.Transaction {
+				response.Results = append(response.Results, batchError(op.ID, http.StatusFailedDependency, "Skipped, an earlier operation failed"))
+				continue
+			}
+
+			result := runBatchOperation(ctx, c, router, op, done)
+			response.Results = append(response.Results, result)
+			if op.ID != "" {
+				done[op.ID] = result
+			}
+			if result.Status >= http.StatusBadRequest {
+				failed = true
+			}
+		}
+
+		if !req.Transaction {
+			response.Committed = true
+			utils.Response(c, http.StatusOK, true, "Batch completed", response)
+			return
+		}
+		if failed {
+			tx.Rollback()
+			utils.Response(c, http.StatusConflict, false, "Batch rolled back", response)
+			return
+		}
+		if err := tx.Commit().Error; err != nil {
+			utils.Response(c, http.StatusInternalServerError, false, "Failed to commit batch", nil)
+			return
+		}
+		response.Committed = true
+		outbox.Notify()
+		utils.Response(c, http.StatusOK, true, "Batch completed", response)
+	}
+}
+
+// runBatchOperation resolves the references of op and serves it through
+// the router so it passes the same middleware as a direct request
+func runBatchOperation(ctx context.Context, c *gin.Context, router http.Handler, op dto.BatchOperation, done map[string]dto.BatchResult) dto.BatchResult {
+	// resolve the references to earlier operations
+	target, err := resolveBatchReferences(op.Path, done, func(value interface{}) string {
+		return url.PathEscape(batchText(value))
+	})
+	if err != nil {
+		return batchError(op.ID, http.StatusFailedDependency, err.Error())
+	}
+	body, err := resolveBatchBody(op.Body, done)
+	if err != nil {
+		return batchError(op.ID, http.StatusFailedDependency, err.Error())
+	}
+
+	sub, err := http.NewRequestWithContext(context.WithValue(ctx, inBatchKey{}, true), op.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return batchError(op.ID, http.StatusBadRequest, "Invalid path")
+	}
+	// the router matches the decoded path, Batch also refuses any
+	// sub-request that reaches it
+	if strings.HasPrefix(path.Clean(sub.URL.Path), "/api/v1/batch") {
+		return batchError(op.ID, http.StatusBadRequest, "Batches cannot be nested")
+	}
+	sub.Header.Set("Content-Type", "application/json")
+	for name, value := range op.Headers {
+		value, err := resolveBatchReferences(value, done, batchText)
+		if err != nil {
+			return batchError(op.ID, http.StatusFailedDependency, err.Error())
+		}
+		sub.Header.Set(name, value)
+	}
+	if batchStreaming(sub) {
+		return batchError(op.ID, http.StatusBadRequest, "Streaming, file and export routes cannot run in a batch")
+	}
+	// sub-requests always act as the caller
+	sub.Header.Set("Authorization", c.GetHeader("Authorization"))
+	sub.RemoteAddr = c.Request.RemoteAddr
+
+	recorder := &batchRecorder{header: http.Header{}}
+	router.ServeHTTP(recorder, sub)
+
+	// build the result
+	result := dto.BatchResult{ID: op.ID, Status: recorder.status, Headers: map[string]string{}}
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	for _, name := range batchResultHeaders {
+		if value := recorder.header.Get(name); value != "" {
+			result.Headers[name] = value
+		}
+	}
+	if recorder.body.Len() > 0 {
+		if json.Valid(recorder.body.Bytes()) {
+			result.Body = json.RawMessage(recorder.body.Bytes())
+		} else {
+			result.Body, _ = json.Marshal(recorder.body.String())
+		}
+	}
+	return result
+}
+
+// batchStreaming reports whether the sub-request reaches a streaming route
+// or asks an analytics report as CSV
+func batchStreaming(sub *http.Request) bool {
+	target := path.Clean(sub.URL.Path)
+	for _, route := range batchStreamingRoutes {
+		if target == route || strings.HasPrefix(target, route+"/") {
+			return true
+		}
+	}
+	if !strings.HasPrefix(target, "/api/v1/analytics/") {
+		return false
+	}
+	format := strings.ToLower(sub.URL.Query().Get("format"))
+	return format == "csv" || format == "" && strings.Contains(sub.Header.Get("Accept"), "text/csv")
+}
+
+// resolveBatchReferences replaces the references in text
+func resolveBatchReferences(text string, done map[string]dto.BatchResult, format func(interface{}) string) (string, error) {
+	var resolveErr error
+	resolved := batchReference.ReplaceAllStringFunc(text, func(match string) string {
+		value, err := lookupBatchReference(match, done)
+		if err != nil {
+			resolveErr = err
+			return match
+		}
+		return format(value)
+	})
+	return resolved, resolveErr
+}
+
+// resolveBatchBody replaces the references in a JSON body, a reference that
+// is a whole JSON string is replaced by the JSON value so ids stay numbers
+func resolveBatchBody(body json.RawMessage, done map[string]dto.BatchResult) ([]byte, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+	var resolveErr error
+	resolved := batchQuotedReference.ReplaceAllStringFunc(string(body), func(match string) string {
+		value, err := lookupBatchReference(match[1:len(match)-1], done)
+		if err != nil {
+			resolveErr = err
+			return match
+		}
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	})
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+
+	// references inside longer strings are replaced by their escaped text
+	text, err := resolveBatchReferences(resolved, done, func(value interface{}) string {
+		encoded, _ := json.Marshal(batchText(value))
+		return string(encoded[1 : len(encoded)-1])
+	})
+	return []byte(text), err
+}
+
+// lookupBatchReference resolves {{op.id}} or {{op.body.a.b}}
+func lookupBatchReference(match string, done map[string]dto.BatchResult) (interface{}, error) {
+	parts := batchReference.FindStringSubmatch(match)
+	id, field := parts[1], parts[2]
+	result, ok := done[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown operation %q", id)
+	}
+	if result.Status >= http.StatusBadRequest {
+		return nil, fmt.Errorf("operation %q failed", id)
+	}
+
+	var body interface{}
+	json.Unmarshal(result.Body, &body)
+	if field == "id" {
+		// the created id comes from the Location header or the body
+		if location := result.Headers["Location"]; location != "" {
+			return batchNumber(path.Base(location)), nil
+		}
+		for _, candidate := range []string{"data.ID", "data.id", "ID", "id"} {
+			if value, ok := batchLookup(body, candidate); ok {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("operation %q did not return an id", id)
+	}
+
+	fieldPath, ok := strings.CutPrefix(field, "body.")
+	if !ok {
+		return nil, fmt.Errorf("reference %q must be %s.id or %s.body.<path>", match, id, id)
+	}
+	value, ok := batchLookup(body, fieldPath)
+	if !ok {
+		return nil, fmt.Errorf("operation %q has no %q in its body", id, fieldPath)
+	}
+	return value, nil
+}
+
+// batchLookup walks a dot path through objects and arrays
+func batchLookup(value interface{}, fieldPath string) (interface{}, bool) {
+	for _, key := range strings.Split(fieldPath, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func batchNumber(text string) interface{} {
+	if n, err := strconv.ParseUint(text, 10, 64); err == nil {
+		return n
+	}
+	return text
+}
+
+func batchText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+func batchError(id string, status int, message string) dto.BatchResult {
+	body, _ := json.Marshal(utils.BaseResponse{Success: false, Code: status, Message: message})
+	return dto.BatchResult{ID: id, Status: status, Body: body}
+}
+
+// batchRecorder captures the response of a sub-request
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *batchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *batchRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// Flush is a no-op, the response is returned once complete
+func (r *batchRecorder) Flush() {}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBatchRefusesStreamingRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/v1/batch", Batch(r))
+	stream := func(c *gin.Context) {
+		// answers once the client leaves, like the feed
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+		<-c.Request.Context().Done()
+	}
+	r.GET("/api/v1/feed", stream)
+	r.GET("/api/v1/export/pokemons", stream)
+	r.GET("/api/v1/analytics/types", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"format": c.Query("format")})
+	})
+
+	body := `{"operations":[
+		{"id":"feed","method":"GET","path":"/api/v1/feed"},
+		{"id":"ws","method":"GET","path":"/api/v1//feed/ws"},
+		{"id":"export","method":"GET","path":"/api/v1/export/pokemons?format=csv"},
+		{"id":"file","method":"GET","path":"/api/v1/files/sprites/1/a.png"},
+		{"id":"csv","method":"GET","path":"/api/v1/analytics/types?format=CSV"},
+		{"id":"accept","method":"GET","path":"/api/v1/analytics/types","headers":{"Accept":"text/csv"}},
+		{"id":"json","method":"GET","path":"/api/v1/analytics/types?format=json"}
+	]}`
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	started := time.Now()
+	r.ServeHTTP(w, req)
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("batch took %v, a streaming route ran", elapsed)
+	}
+
+	var response struct {
+		Data dto.BatchResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"feed": 400, "ws": 400, "export": 400, "file": 400, "csv": 400, "accept": 400, "json": 200}
+	for _, result := range response.Data.Results {
+		if result.Status != want[result.ID] {
+			t.Errorf("%s: status %d, want %d", result.ID, result.Status, want[result.ID])
+		}
+	}
+	if len(response.Data.Results) != len(want) {
+		t.Errorf("got %d results, want %d", len(response.Data.Results), len(want))
+	}
+}
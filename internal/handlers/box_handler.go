@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"errors"
+	"go-api/internal/dto"
+	"go-api/internal/events"
+	"go-api/internal/models"
+	"go-api/internal/outbox"
+	"go-api/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errBoxNotFound         = errors.New("Box not found")
+	errBoxPokemonNotFound  = errors.New("Pokemon not found")
+	errBoxCapacityExceeded = errors.New("Box capacity exceeded")
+	errBoxNameUsed         = errors.New("Box name already used")
+)
+
+// Get all boxes
+
+// Box routes
+// GetBoxes godoc
+// @Summary      Get my boxes
+// @Description  Get the boxes of the logged in user with their item count
+// @Tags         Boxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to fetch boxes"
+// @Router 		 /boxes [get]
+func GetBoxes(c *gin.Context) {
+	email := c.GetString("email")
+
+	var boxes []dto.BoxResponse
+	if err := utils.DB(c).Model(&models.Box{}).
+		Select("boxes.id, boxes.name, boxes.capacity, COUNT(box_pokemons.pokemon_id) AS count").
+		Joins("LEFT JOIN box_pokemons ON box_pokemons.box_id = boxes.id").
+		Where("boxes.user_email = ?", email).
+		Group("boxes.id").
+		Order("boxes.name asc").
+		Scan(&boxes).Error; err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to fetch boxes", nil)
+		return
+	}
+
+	utils.Response(c, http.StatusOK, true, "Succes fetching boxes data", boxes)
+}
+
+// Get Box by ID
+// GetBox godoc
+// @Summary      Get box by id
+// @Description  Get a box of the logged in user with its pokemons
+// @Tags         Boxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      404    {object}  utils.BaseResponse  "Box not found"
+// @Router 		 /box [get]
+func GetBoxByID(c *gin.Context) {
+	id, ok := utils.ResourceID(c)
+	var box models.Box
+	if err := utils.DB(c).Preload("Pokemons.Tags").
+		Where("user_email = ?", c.GetString("email")).
+		First(&box, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Box not found", nil)
+		return
+	}
+
+	utils.Response(c, http.StatusOK, true, "Succes fetching box data", box)
+}
+
+// Create Box
+// CreateBox godoc
+// @Summary      Create new box
+// @Description  Create a named box with a capacity for the logged in user
+// @Tags         Boxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        credentials body dto.CreateBoxRequest true "Create box credentials"
+// @Param        Idempotency-Key  header  string  false  "Replay the stored response when the request is retried"
+// @Success      201 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Validation error"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      409 {object} utils.BaseResponse "Box name already used"
+// @Failure      500 {object} utils.BaseResponse "Failed to create box"
+// @Router       /box/create [post]
+func CreateBox(c *gin.Context) {
+	// get the serializer and validate it
+	var req dto.CreateBoxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	box := models.Box{
+		UserEmail: c.GetString("email"),
+		Name:      req.Name,
+		Capacity:  req.Capacity,
+	}
+
+	// save the box
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := tx.Create(&box).Error; err != nil {
+			if utils.IsUniqueViolation(tx, err) {
+				return errBoxNameUsed
+			}
+			return err
+		}
+		return outbox.Record(tx, outbox.Box, box.ID, events.Event{Type: events.BoxCreated, UserEmail: box.UserEmail, Payload: box})
+	})
+	if errors.Is(err, errBoxNameUsed) {
+		utils.Response(c, http.StatusConflict, false, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to create box", nil)
+		return
+	}
+
+	utils.Response(c, http.StatusCreated, true, "Box created", box)
+}
+
+// Update Box
+// UpdateBox godoc
+// @Summary      Update box
+// @Description  Rename a box or change its capacity
+// @Tags         Boxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Param        credentials body dto.UpdateBoxRequest true "Update box credentials"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Validation error"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      404 {object} utils.BaseResponse "Box not found"
+// @Failure      409 {object} utils.BaseResponse "Box capacity exceeded or name already used"
+// @Failure      500 {object} utils.BaseResponse "Failed to update box"
+// @Router       /box/update [put]
+func UpdateBox(c *gin.Context) {
+	// get the serializer and validate it
+	var req dto.UpdateBoxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	id, ok := utils.ResourceID(c)
+	var box models.Box
+	if err := utils.DB(c).Where("user_email = ?", c.GetString("email")).First(&box, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Box not found", nil)
+		return
+	}
+
+	if req.Name != "" {
+		box.Name = req.Name
+	}
+	if req.Capacity != 0 {
+		count := utils.DB(c).Model(&box).Association("Pokemons").Count()
+		if int64(req.Capacity) < count {
+			utils.Response(c, http.StatusConflict, false, errBoxCapacityExceeded.Error(), nil)
+			return
+		}
+		box.Capacity = req.Capacity
+	}
+
+	// save the box
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := tx.Save(&box).Error; err != nil {
+			if utils.IsUniqueViolation(tx, err) {
+				return errBoxNameUsed
+			}
+			return err
+		}
+		return outbox.Record(tx, outbox.Box, box.ID, events.Event{Type: events.BoxUpdated, UserEmail: box.UserEmail, Payload: box})
+	})
+	if errors.Is(err, errBoxNameUsed) {
+		utils.Response(c, http.StatusConflict, false, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to update box", nil)
+		return
+	}
+	utils.Response(c, http.StatusOK, true, "Box updated", box)
+}
+
+// Delete Box
+// DeleteBox godoc
+// @Summary      Delete box
+// @Description  Delete a box, the pokemons inside are kept
+// @Tags         Boxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      404 {object} utils.BaseResponse "Box not found"
+// @Router       /box/delete [delete]
+func DeleteBox(c *gin.Context) {
+	id, ok := utils.ResourceID(c)
+	var box models.Box
+	if err := utils.DB(c).Where("user_email = ?", c.GetString("email")).First(&box, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Box not found", nil)
+		return
+	}
+
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := tx.Model(&box).Association("Pokemons").Clear(); err != nil {
+			return err
+		}
+		// deleted for good so the name can be used again
+		if err := tx.Unscoped().Delete(&box).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, outbox.Box, box.ID, events.Event{Type: events.BoxDeleted, UserEmail: box.UserEmail, Payload: box})
+	})
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to delete box", nil)
+		return
+	}
+	utils.Response(c, http.StatusOK, true, "Box deleted", box)
+}
+
+// Move pokemons between boxes
+// MoveBoxPokemons godoc
+// @Summary      Move pokemons between boxes
+// @Description  Move pokemons to another box, removing them from the source box given by fromBoxId
+// @Tags         Boxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        credentials body dto.TransferBoxPokemonsRequest true "Move request"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Validation error or missing fromBoxId"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      404 {object} utils.BaseResponse "Box or pokemon not found"
+// @Failure      409 {object} utils.BaseResponse "Box capacity exceeded"
+// @Router       /box/move [post]
+func MoveBoxPokemons(c *gin.Context) {
+	transferBoxPokemons(c, true)
+}
+
+// Copy pokemons between boxes
+// CopyBoxPokemons godoc
+// @Summary      Copy pokemons between boxes
+// @Description  Copy pokemons to another box, keeping them in the source box
+// @Tags         Boxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        credentials body dto.TransferBoxPokemonsRequest true "Copy request"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Validation error"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      404 {object} utils.BaseResponse "Box or pokemon not found"
+// @Failure      409 {object} utils.BaseResponse "Box capacity exceeded"
+// @Router       /box/copy [post]
+func CopyBoxPokemons(c *gin.Context) {
+	transferBoxPokemons(c, false)
+}
+
+// transferBoxPokemons adds pokemons to the target box and, when moving,
+// removes them from the source box
+func transferBoxPokemons(c *gin.Context, move bool) {
+	var req dto.TransferBoxPokemonsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	// without a source box a move would only copy
+	if move && req.FromBoxID == 0 {
+		utils.Response(c, http.StatusBadRequest, false, "fromBoxId is required to move pokemons", nil)
+		return
+	}
+	email := c.GetString("email")
+
+	var target models.Box
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		// the lock holds concurrent transfers to the box until the capacity
+		// check of this one is committed
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_email = ?", email).First(&target, req.ToBoxID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errBoxNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var pokemons []models.Pokemon
+		if err := tx.Where("user_email = ? AND id IN ?", email, req.PokemonIDs).Find(&pokemons).Error; err != nil {
+			return err
+		}
+		if len(pokemons) != len(uniqueIDs(req.PokemonIDs)) {
+			return errBoxPokemonNotFound
+		}
+
+		// capacity check, pokemons already in the box are not counted twice
+		var current, alreadyIn int64
+		if err := tx.Table("box_pokemons").Where("box_id = ?", target.ID).Count(&current).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("box_pokemons").Where("box_id = ? AND pokemon_id IN ?", target.ID, req.PokemonIDs).Count(&alreadyIn).Error; err != nil {
+			return err
+		}
+		if current+int64(len(pokemons))-alreadyIn > int64(target.Capacity) {
+			return errBoxCapacityExceeded
+		}
+
+		if move {
+			var source models.Box
+			if err := tx.Where("user_email = ?", email).First(&source, req.FromBoxID).Error; err != nil {
+				return errBoxNotFound
+			}
+			var inSource int64
+			if err := tx.Table("box_pokemons").Where("box_id = ? AND pokemon_id IN ?", source.ID, req.PokemonIDs).Count(&inSource).Error; err != nil {
+				return err
+			}
+			if inSource != int64(len(pokemons)) {
+				return errBoxPokemonNotFound
+			}
+			if err := tx.Model(&source).Association("Pokemons").Delete(&pokemons); err != nil {
+				return err
+			}
+			if err := outbox.Record(tx, outbox.Box, source.ID, events.Event{Type: events.BoxUpdated, UserEmail: source.UserEmail, Payload: source}); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&target).Association("Pokemons").Append(&pokemons); err != nil {
+			return err
+		}
+		return outbox.Record(tx, outbox.Box, target.ID, events.Event{Type: events.BoxUpdated, UserEmail: target.UserEmail, Payload: target})
+	})
+
+	switch {
+	case errors.Is(err, errBoxNotFound), errors.Is(err, errBoxPokemonNotFound):
+		utils.Response(c, http.StatusNotFound, false, err.Error(), nil)
+	case errors.Is(err, errBoxCapacityExceeded):
+		utils.Response(c, http.StatusConflict, false, err.Error(), nil)
+	case err != nil:
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to transfer pokemons", nil)
+	case move:
+		utils.Response(c, http.StatusOK, true, "Pokemons moved", target)
+	default:
+		utils.Response(c, http.StatusOK, true, "Pokemons copied", target)
+	}
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := map[uint]bool{}
+	unique := []uint{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"go-api/config"
+	"go-api/internal/export"
+	"go-api/internal/models"
+	"go-api/internal/storage"
+	"go-api/internal/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Export routes
+// ExportPokemons godoc
+// @Summary      Export pokemons
+// @Description  Stream every pokemon matching the GetPokemons filters as CSV, NDJSON or XLSX. Exports above EXPORT_ASYNC_ROWS rows, or with async=true, run as a background job.
+// @Tags         Export
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security     BearerAuth
+// @Param        format   query     string  false  "csv, ndjson or xlsx, defaults to the Accept header"
+// @Param        async    query     bool    false  "Run the export as a background job"
+// @Success      200    {file}    file
+// @Success      202    {object}  utils.BaseResponse  "Export job queued"
+// @Failure      400    {object}  utils.BaseResponse  "Invalid filter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      406    {object}  utils.BaseResponse  "Unsupported format"
+// @Router 		 /export/pokemons [get]
+func ExportPokemons(c *gin.Context) {
+	exportResource(c, "pokemons")
+}
+
+// ExportUsers godoc
+// @Summary      Export users
+// @Description  Stream every user matching the GetUsers filters as CSV, NDJSON or XLSX. Exports above EXPORT_ASYNC_ROWS rows, or with async=true, run as a background job.
+// @Tags         Export
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security     BearerAuth
+// @Param        format   query     string  false  "csv, ndjson or xlsx, defaults to the Accept header"
+// @Param        async    query     bool    false  "Run the export as a background job"
+// @Success      200    {file}    file
+// @Success      202    {object}  utils.BaseResponse  "Export job queued"
+// @Failure      400    {object}  utils.BaseResponse  "Invalid filter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      406    {object}  utils.BaseResponse  "Unsupported format"
+// @Router 		 /export/users [get]
+func ExportUsers(c *gin.Context) {
+	exportResource(c, "users")
+}
+
+// GetExportJob godoc
+// @Summary      Get export job
+// @Description  Get the status of an export job, finished jobs carry a signed download URL
+// @Tags         Export
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      404    {object}  utils.BaseResponse  "Export job not found"
+// @Router 		 /export/job [get]
+func GetExportJob(c *gin.Context) {
+	id, ok := utils.ResourceID(c)
+	var job models.ExportJob
+
+	if err := utils.DB(c).Where("user_email = ?", c.GetString("email")).First(&job, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Export job not found", nil)
+		return
+	}
+
+	// build the reponse
+	dataResponse := gin.H{"job": job}
+	if job.Status == models.ExportDone {
+		dataResponse["url"] = storage.SignURL(job.Key, signedURLTTL)
+		dataResponse["expiresIn"] = int(signedURLTTL.Seconds())
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching export job", dataResponse)
+}
+
+// ExportSource builds the filtered export query of a resource, it is also
+// used to resume background jobs after a restart
+func ExportSource(db *gorm.DB, resource string, query url.Values) (*gorm.DB, export.Table, error) {
+	switch resource {
+	case "pokemons":
+		filtered, err := filterPokemons(db.Model(&models.Pokemon{}), query)
+		return filtered.Order("id"), pokemonExportTable, err
+	case "users":
+		filter, err := utils.ParseFilterValues(query, userFilterFields)
+		if err != nil {
+			return db, export.Table{}, err
+		}
+		return filter.Apply(db.Model(&models.User{})).Order("id"), userExportTable, nil
+	}
+	return db, export.Table{}, fmt.Errorf("unknown export %q", resource)
+}
+
+var pokemonExportTable = export.Table{
+	Columns: []string{"id", "user_email", "name", "type", "notes", "sprite", "shiny", "form", "gender", "created_at", "updated_at"},
+	Scan: func(db *gorm.DB, rows *sql.Rows) ([]interface{}, error) {
+		var p models.Pokemon
+		if err := db.ScanRows(rows, &p); err != nil {
+			return nil, err
+		}
+		return []interface{}{p.ID, p.UserEmail, p.Name, p.Type, p.Notes, p.Sprite, p.Shiny, p.Form, p.Gender, p.CreatedAt, p.UpdatedAt}, nil
+	},
+}
+
+var userExportTable = export.Table{
+	Columns: []string{"id", "name", "email", "role", "created_at", "updated_at"},
+	Scan: func(db *gorm.DB, rows *sql.Rows) ([]interface{}, error) {
+		var u models.User
+		if err := db.ScanRows(rows, &u); err != nil {
+			return nil, err
+		}
+		return []interface{}{u.ID, u.Name, u.Email, u.Role, u.CreatedAt, u.UpdatedAt}, nil
+	},
+}
+
+func exportResource(c *gin.Context, resource string) {
+	format, err := export.Negotiate(c.Query("format"), c.GetHeader("Accept"))
+	if err != nil {
+		utils.Response(c, http.StatusNotAcceptable, false, err.Error(), nil)
+		return
+	}
+
+	// the synchronous export reads through the request database and stops
+	// when the client leaves, background jobs outlive the request
+	query := c.Request.URL.Query()
+	requestDB := utils.DB(c).WithContext(c.Request.Context())
+	db, table, err := ExportSource(requestDB, resource, query)
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	// large exports run in the background
+	async := c.Query("async") == "true"
+	if !async {
+		var count int64
+		counted, _, _ := ExportSource(requestDB, resource, query)
+		if err := counted.Count(&count).Error; err != nil {
+			utils.Response(c, http.StatusInternalServerError, false, "Failed to count export rows", nil)
+			return
+		}
+		async = count > exportAsyncRows()
+	}
+	if async {
+		job := models.ExportJob{
+			UserEmail: c.GetString("email"),
+			Resource:  resource,
+			Format:    string(format),
+			Query:     c.Request.URL.RawQuery,
+		}
+		if err := export.Enqueue(config.DB, &job, ExportSource); err != nil {
+			utils.Response(c, http.StatusInternalServerError, false, "Failed to queue export", nil)
+			return
+		}
+		c.Header("Location", fmt.Sprintf("/api/v1/export/job?id=%d", job.ID))
+		utils.Response(c, http.StatusAccepted, true, "Export job queued", job)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s%s", resource, time.Now().Format("20060102"), format.Extension())
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if _, err := export.Stream(db, table, format, c.Writer); err != nil {
+		// the status is already sent, the client gets a truncated file
+		log.Printf("export of %s failed: %v", resource, err)
+		c.Error(err)
+	}
+}
+
+// exportAsyncRows is the row count above which exports run in the background
+func exportAsyncRows() int64 {
+	rows, err := strconv.ParseInt(strings.TrimSpace(config.GetEnv("EXPORT_ASYNC_ROWS")), 10, 64)
+	if err != nil {
+		return 50000 // Default threshold
+	}
+	return rows
+}
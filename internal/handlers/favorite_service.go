@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"go-api/internal/dto"
+	"go-api/internal/events"
+	"go-api/internal/middleware"
+	"go-api/internal/models"
+	"go-api/internal/outbox"
+	"go-api/internal/utils"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// The favorite operations below are shared by the REST handlers, the
+// GraphQL resolvers and the gRPC service. Failures are *utils.StatusError
+// so every transport answers them the same way.
+
+// findFavorite loads a favorite by id
+func findFavorite(db *gorm.DB, id uint) (models.Pokemon, error) {
+	var pokemon models.Pokemon
+	if err := db.First(&pokemon, id).Error; id == 0 || err != nil {
+		return pokemon, &utils.StatusError{Code: http.StatusNotFound, Message: "Pokemon not found"}
+	}
+	return pokemon, nil
+}
+
+// canEditFavorite reports whether the user may change the favorite, only its
+// owner and the admins can
+func canEditFavorite(email, role string, pokemon models.Pokemon) bool {
+	return pokemon.UserEmail == email || middleware.HasRole(role, "admin")
+}
+
+// createFavorite validates the variant, attaches the tags of the owner and
+// saves the favorite
+func createFavorite(db *gorm.DB, req dto.CreateFavoritePokemonRequest) (models.Pokemon, error) {
+	pokemon := models.Pokemon{
+		Name:      req.Name,
+		Type:      req.Type,
+		Notes:     req.Notes,
+		Sprite:    req.Sprite,
+		Shiny:     req.Shiny,
+		Form:      req.Form,
+		Gender:    req.Gender,
+		UserEmail: req.UserEmail,
+	}
+
+	// validate the variant and pick its sprite
+	if err := applyVariant(&pokemon); err != nil {
+		return pokemon, &utils.StatusError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	err := outbox.Transaction(db, func(tx *gorm.DB) error {
+		// attach the tags of the owner
+		tags, err := findOrCreateTags(tx, req.UserEmail, req.Tags)
+		if err != nil {
+			return &utils.StatusError{Code: http.StatusInternalServerError, Message: "Failed to create tags"}
+		}
+		pokemon.Tags = tags
+
+		if err := tx.Create(&pokemon).Error; err != nil {
+			return &utils.StatusError{Code: http.StatusConflict, Message: "Pokemon email already used"}
+		}
+		return recordFavoriteEvent(tx, events.PokemonCreated, pokemon)
+	})
+	return pokemon, err
+}
+
+// favoriteDocument is the editable state of a favorite
+func favoriteDocument(pokemon models.Pokemon) dto.UpdateFavoritePokemonRequest {
+	return dto.UpdateFavoritePokemonRequest{
+		Name:   pokemon.Name,
+		Type:   pokemon.Type,
+		Notes:  pokemon.Notes,
+		Shiny:  pokemon.Shiny,
+		Form:   pokemon.Form,
+		Gender: pokemon.Gender,
+	}
+}
+
+// updateFavorite applies doc, validates the variant and writes the columns
+// if the version did not change, no columns means all of them
+func updateFavorite(db *gorm.DB, pokemon *models.Pokemon, doc dto.UpdateFavoritePokemonRequest, columns ...string) error {
+	pokemon.Name = doc.Name
+	pokemon.Type = doc.Type
+	pokemon.Notes = doc.Notes
+	pokemon.Shiny = doc.Shiny
+	pokemon.Form = doc.Form
+	pokemon.Gender = doc.Gender
+
+	// validate the variant and pick its sprite
+	sprite := pokemon.Sprite
+	if err := applyVariant(pokemon); err != nil {
+		return &utils.StatusError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if len(columns) > 0 && pokemon.Sprite != sprite {
+		columns = append(columns, "sprite")
+	}
+
+	return outbox.Transaction(db, func(tx *gorm.DB) error {
+		if err := utils.UpdateVersioned(tx, pokemon, &pokemon.Version, columns...); errors.Is(err, utils.ErrVersionConflict) {
+			return &utils.StatusError{Code: http.StatusPreconditionFailed, Message: err.Error()}
+		} else if err != nil {
+			return &utils.StatusError{Code: http.StatusInternalServerError, Message: "Failed to update pokemon"}
+		}
+		return recordFavoriteEvent(tx, events.PokemonUpdated, *pokemon)
+	})
+}
+
+// deleteFavorite deletes the favorite if the version did not change
+func deleteFavorite(db *gorm.DB, pokemon models.Pokemon) error {
+	return outbox.Transaction(db, func(tx *gorm.DB) error {
+		if err := utils.DeleteVersioned(tx, &pokemon, pokemon.Version); errors.Is(err, utils.ErrVersionConflict) {
+			return &utils.StatusError{Code: http.StatusPreconditionFailed, Message: err.Error()}
+		} else if err != nil {
+			return &utils.StatusError{Code: http.StatusInternalServerError, Message: "Failed to delete pokemon"}
+		}
+		return recordFavoriteEvent(tx, events.PokemonDeleted, pokemon)
+	})
+}
+
+// recordFavoriteEvent writes the event of a favorite change in its transaction
+func recordFavoriteEvent(tx *gorm.DB, eventType string, pokemon models.Pokemon) error {
+	event := events.Event{Type: eventType, UserEmail: pokemon.UserEmail, Payload: pokemon}
+	if err := outbox.Record(tx, outbox.Pokemon, pokemon.ID, event); err != nil {
+		return &utils.StatusError{Code: http.StatusInternalServerError, Message: "Failed to record event"}
+	}
+	return nil
+}
+
+// expectVersion is the If-Match check of the transports without headers,
+// version 0 accepts any version
+func expectVersion(pokemon models.Pokemon, version uint) error {
+	if version != 0 && version != pokemon.Version {
+		return &utils.StatusError{Code: http.StatusPreconditionFailed, Message: utils.ErrVersionConflict.Error()}
+	}
+	return nil
+}
+
+// listFavorites pages the favorites matching the GetPokemons filters of query
+func listFavorites(db *gorm.DB, query url.Values) (utils.DataResponse, []models.Pokemon, error) {
+	db, err := filterPokemons(db.Model(&models.Pokemon{}), query)
+	if err != nil {
+		return utils.DataResponse{}, nil, &utils.StatusError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	var pokemons []models.Pokemon
+	page, err := fetchPage(db, query, pokemonSortFields, &pokemons)
+	return page, pokemons, err
+}
+
+// pokemonSortFields and userSortFields are the sort columns of the lists
+var (
+	pokemonSortFields = map[string]bool{"id": true, "name": true, "type": true, "notes": true, "created_at": true, "updated_at": true}
+	userSortFields    = map[string]bool{"id": true, "name": true, "email": true, "role": true, "created_at": true, "updated_at": true}
+)
+
+// setListSort sets the sort parameters of a list query, the column comes
+// from a whitelist since the offset sort is raw SQL
+func setListSort(query url.Values, sortable map[string]bool, sortBy, order string) error {
+	if sortBy == "" {
+		return nil
+	}
+	if !sortable[sortBy] {
+		return &utils.StatusError{Code: http.StatusBadRequest, Message: fmt.Sprintf("cannot sort by %q", sortBy)}
+	}
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		return &utils.StatusError{Code: http.StatusBadRequest, Message: "order must be asc or desc"}
+	}
+	query.Set("sort_by", sortBy)
+	query.Set("order", order)
+	query.Set("sort", sortBy+" "+order)
+	return nil
+}
+
+// addFilterCondition adds a field[op]=value condition to a list query, or
+// or[group][field][op]=value with the prefix of a group
+func addFilterCondition(query url.Values, prefix, field, op, value string) {
+	if op == "" {
+		op = utils.OpEq
+	}
+	if prefix == "" {
+		query.Add(fmt.Sprintf("%s[%s]", field, op), value)
+		return
+	}
+	query.Add(fmt.Sprintf("%s[%s][%s]", prefix, field, op), value)
+}
+
+// fetchPage pages db into items like the REST lists
+func fetchPage[T any](db *gorm.DB, query url.Values, sortable map[string]bool, items *[]T) (utils.DataResponse, error) {
+	var model T
+	db, pagination := utils.ApplyPaginationValues(query, db, &model, sortable)
+	if pagination.Err != nil {
+		return utils.DataResponse{}, &utils.StatusError{Code: http.StatusBadRequest, Message: pagination.Err.Error()}
+	}
+	if err := db.Find(items).Error; err != nil {
+		return utils.DataResponse{}, err
+	}
+	utils.FinalizePagination(&pagination, items)
+
+	return utils.DataResponse{
+		CurrentPage:     pagination.Page,
+		TotalPages:      pagination.TotalPages,
+		TotalItems:      pagination.TotalItems,
+		Limit:           pagination.Limit,
+		HasNextPage:     pagination.HasNextPage,
+		HasPreviousPage: pagination.HasPreviousPage,
+		NextCursor:      pagination.NextCursor,
+		PrevCursor:      pagination.PrevCursor,
+		Items:           *items,
+	}, nil
+}
+
+// favoriteTag is a tag of a favorite as loaded through pokemon_tags
+type favoriteTag struct {
+	PokemonID uint   `json:"-"`
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+}
+
+// favoriteTags loads the tags of the favorites in one query
+func favoriteTags(db *gorm.DB, ids []uint) (map[uint][]favoriteTag, error) {
+	var rows []favoriteTag
+	err := db.Table("tags").
+		Select("pokemon_tags.pokemon_id, tags.id, tags.name").
+		Joins("JOIN pokemon_tags ON pokemon_tags.tag_id = tags.id").
+		Where("pokemon_tags.pokemon_id IN ? AND tags.deleted_at IS NULL", ids).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	tags := map[uint][]favoriteTag{}
+	for _, id := range ids {
+		tags[id] = []favoriteTag{}
+	}
+	for _, row := range rows {
+		tags[row.PokemonID] = append(tags[row.PokemonID], row)
+	}
+	return tags, nil
+}
+
+// validateRequest applies the binding rules of a request that was not bound
+// by gin
+func validateRequest(req interface{}) error {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return &utils.StatusError{Code: http.StatusUnprocessableEntity, Message: fmt.Sprintf("Validation failed: %s", strings.Join(utils.ValidationMessages(err), ", "))}
+	}
+	return nil
+}
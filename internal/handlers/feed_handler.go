@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-api/config"
+	"go-api/internal/events"
+	"go-api/internal/feed"
+	"go-api/internal/middleware"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// feedEvent is a feed message as sent to the clients
+type feedEvent struct {
+	ID string `json:"id,omitempty"`
+	events.Event
+}
+
+// feedReset tells a resuming client that events were missed and its state
+// must be refetched
+const feedReset = "feed.reset"
+
+// feed clients reconnect after this delay
+const feedRetry = 3 * time.Second
+
+// a feed client that does not read a message for this long is dropped,
+// instead of holding its connection and subscription forever
+const feedWriteTimeout = 10 * time.Second
+
+// the CORS policy allows every origin and the token is not a cookie, so
+// cross origin sockets are accepted like cross origin requests
+var feedUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Feed routes
+// StreamFeed godoc
+// @Summary      Change feed (Server-Sent Events)
+// @Description  Streams the created, updated and deleted events of the caller's favorites, or of every resource to admins. Reconnect with Last-Event-ID to receive the events missed meanwhile; a feed.reset event means they are no longer available and the state must be refetched. Comments are sent as heartbeats. Clients that cannot set headers may pass the token as access_token.
+// @Tags         Feed
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        Last-Event-ID  header  string  false  "Id of the last event received"
+// @Param        last_event_id  query   string  false  "Same as Last-Event-ID"
+// @Param        access_token   query   string  false  "JWT when the Authorization header cannot be set"
+// @Success      200 {string} string "Event stream"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Router       /feed [get]
+func StreamFeed(c *gin.Context) {
+	subscription, replay, complete := feed.Default.Subscribe(feedLastEventID(c), feedFilter(c))
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// every write gets a deadline, a failed write ends the stream
+	rc := http.NewResponseController(c.Writer)
+	rc.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+	_, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", feedRetry.Milliseconds())
+	if err == nil && !complete {
+		err = writeFeedEvent(c.Writer, feedEvent{Event: events.Event{Type: feedReset, OccurredAt: time.Now()}})
+	}
+	for _, message := range replay {
+		if err == nil {
+			err = writeFeedEvent(c.Writer, feedEvent{ID: message.ID, Event: message.Event})
+		}
+	}
+	if err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(feedHeartbeat())
+	defer heartbeat.Stop()
+	expired := feedTokenExpiry(c)
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-expired:
+			return
+		case message, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+			rc.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+			err = writeFeedEvent(c.Writer, feedEvent{ID: message.ID, Event: message.Event})
+		case <-heartbeat.C:
+			rc.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+			_, err = fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// SocketFeed godoc
+// @Summary      Change feed (WebSocket)
+// @Description  The events of GET /feed as JSON text messages over a WebSocket, with ping frames as heartbeats. The socket is closed with 1013 when the client falls behind and with 1008 when the token expires, reconnect with last_event_id to resume.
+// @Tags         Feed
+// @Security     BearerAuth
+// @Param        last_event_id  query   string  false  "Id of the last event received"
+// @Param        access_token   query   string  false  "JWT when the Authorization header cannot be set"
+// @Success      101 {string} string "Switching protocols"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Router       /feed/ws [get]
+func SocketFeed(c *gin.Context) {
+	conn, err := feedUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // the upgrader answered the error
+	}
+	defer conn.Close()
+
+	subscription, replay, complete := feed.Default.Subscribe(feedLastEventID(c), feedFilter(c))
+	defer subscription.Close()
+
+	// the reader handles the pongs and notices when the client goes away,
+	// closing the connection then unblocks a pending write
+	interval := feedHeartbeat()
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(2 * interval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * interval))
+	})
+	go func() {
+		defer conn.Close()
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if !complete {
+		if err := writeFeedSocket(conn, feedEvent{Event: events.Event{Type: feedReset, OccurredAt: time.Now()}}); err != nil {
+			return
+		}
+	}
+	for _, message := range replay {
+		if err := writeFeedSocket(conn, feedEvent{ID: message.ID, Event: message.Event}); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+	expired := feedTokenExpiry(c)
+
+	for {
+		var err error
+		select {
+		case <-closed:
+			return
+		case <-expired:
+			closeFeedSocket(conn, websocket.ClosePolicyViolation, "token expired")
+			return
+		case message, ok := <-subscription.C:
+			if !ok {
+				closeFeedSocket(conn, websocket.CloseTryAgainLater, "client too slow, resume from the last event")
+				return
+			}
+			err = writeFeedSocket(conn, feedEvent{ID: message.ID, Event: message.Event})
+		case <-heartbeat.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(feedWriteTimeout))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeFeedSocket(conn *websocket.Conn, event feedEvent) error {
+	conn.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+	return conn.WriteJSON(event)
+}
+
+func closeFeedSocket(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
+
+func writeFeedEvent(w io.Writer, event feedEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil // skip the event
+	}
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
+// feedFilter authorizes the events of a connection, users only receive the
+// events of their own favorites
+func feedFilter(c *gin.Context) feed.Filter {
+	if middleware.HasRole(c.GetString("role"), "admin") {
+		return func(events.Event) bool { return true }
+	}
+	email := c.GetString("email")
+	return func(e events.Event) bool {
+		return e.UserEmail == email && strings.HasPrefix(e.Type, "pokemon.")
+	}
+}
+
+func feedLastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
+
+// feedTokenExpiry fires when the token of the connection expires, nil never
+// fires
+func feedTokenExpiry(c *gin.Context) <-chan time.Time {
+	expiresAt, ok := c.Get("tokenExpiresAt")
+	if !ok {
+		return nil
+	}
+	return time.After(time.Until(expiresAt.(time.Time)))
+}
+
+// feedHeartbeat reads the heartbeat interval from FEED_HEARTBEAT (e.g. 25s)
+func feedHeartbeat() time.Duration {
+	interval, err := time.ParseDuration(config.GetEnv("FEED_HEARTBEAT"))
+	if err != nil || interval <= 0 {
+		interval = 25 * time.Second // Default interval
+	}
+	return interval
+}
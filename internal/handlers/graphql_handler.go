@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/internal/dto"
+	"go-api/internal/gql"
+	"go-api/internal/middleware"
+	"go-api/internal/models"
+	"go-api/internal/species"
+	"go-api/internal/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"gorm.io/gorm"
+)
+
+// graphqlContext is the per request state of the resolvers, the loaders
+// batch the relations of the favorites of one query level
+type graphqlContext struct {
+	c       *gin.Context
+	owners  *gql.Loader[string, dto.OwnerResponse]
+	species *gql.Loader[string, models.Species]
+	tags    *gql.Loader[uint, []favoriteTag]
+}
+
+type graphqlContextKey struct{}
+
+// GraphQL routes
+// GraphQL godoc
+// @Summary      GraphQL endpoint
+// @Description  Query users, favorites and species and mutate favorites in one round trip. The users queries are admin only. Queries deeper than GRAPHQL_MAX_DEPTH or costlier than GRAPHQL_MAX_COMPLEXITY are rejected.
+// @Tags         GraphQL
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body dto.GraphQLRequest true "Query, operation name and variables"
+// @Success      200 {object} graphql.Result
+// @Failure      400 {object} graphql.Result "Invalid request or query too complex"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Router       /graphql [post]
+func GraphQL(c *gin.Context) {
+	// get the serializer and validate it
+	var req dto.GraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	// GraphQL clients expect the spec response rather than utils.Response
+	limits := gql.Limits{MaxDepth: graphqlLimit("GRAPHQL_MAX_DEPTH", 10), MaxComplexity: graphqlLimit("GRAPHQL_MAX_COMPLEXITY", 1000), MaxLimit: utils.MaxLimit}
+	if err := limits.Check(&graphqlSchema, req.Query, req.OperationName, req.Variables); err != nil {
+		c.JSON(http.StatusBadRequest, graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), graphqlContextKey{}, newGraphQLContext(c))
+	result := graphql.Do(graphql.Params{
+		Schema:         graphqlSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+func newGraphQLContext(c *gin.Context) *graphqlContext {
+	db := utils.DB(c)
+	return &graphqlContext{
+		c: c,
+		owners: gql.NewLoader(func(emails []string) (map[string]dto.OwnerResponse, error) {
+			var users []models.User
+			if err := db.Select("id", "name", "email").Where("email IN ?", emails).Find(&users).Error; err != nil {
+				return nil, err
+			}
+			owners := map[string]dto.OwnerResponse{}
+			for _, u := range users {
+				owners[u.Email] = dto.OwnerResponse{ID: u.ID, Name: u.Name, Email: u.Email}
+			}
+			return owners, nil
+		}),
+		species: gql.NewLoader(func(names []string) (map[string]models.Species, error) {
+			var rows []models.Species
+			if err := db.Where("name IN ?", names).Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			catalog := map[string]models.Species{}
+			for _, s := range rows {
+				catalog[s.Name] = s
+			}
+			return catalog, nil
+		}),
+		tags: gql.NewLoader(func(ids []uint) (map[uint][]favoriteTag, error) {
+			return favoriteTags(db, ids)
+		}),
+	}
+}
+
+func graphqlFrom(p graphql.ResolveParams) *graphqlContext {
+	return p.Context.Value(graphqlContextKey{}).(*graphqlContext)
+}
+
+// requireRole applies the RoleMiddleware ranking to a field
+func (g *graphqlContext) requireRole(role string) error {
+	if !middleware.HasRole(g.c.GetString("role"), role) {
+		return errors.New("Forbidden: Insufficient permissions")
+	}
+	return nil
+}
+
+// Query resolvers
+
+func resolveMe(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	var user models.User
+	if err := utils.DB(g.c).Where("email = ?", g.c.GetString("email")).First(&user).Error; err != nil {
+		return nil, errors.New("User not found")
+	}
+	return user, nil
+}
+
+func resolveUser(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	if err := g.requireRole("admin"); err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := utils.DB(g.c).First(&user, graphqlID(p)).Error; err != nil {
+		return nil, errors.New("User not found")
+	}
+	return user, nil
+}
+
+func resolveUsers(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	if err := g.requireRole("admin"); err != nil {
+		return nil, err
+	}
+	query, err := graphqlListQuery(p.Args, userSortFields)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := utils.ParseFilterValues(query, userFilterFields)
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	page, err := fetchPage(filter.Apply(utils.DB(g.c).Model(&models.User{})), query, userSortFields, &users)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func resolveFavorite(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	pokemon, err := findFavorite(utils.DB(g.c), graphqlID(p))
+	if err != nil {
+		return nil, err
+	}
+	return pokemon, nil
+}
+
+func resolveFavorites(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	return graphqlFavorites(p, utils.DB(g.c))
+}
+
+func resolveUserFavorites(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	user := p.Source.(models.User)
+	return graphqlFavorites(p, utils.DB(g.c).Where("user_email = ?", user.Email))
+}
+
+func resolveSpecies(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	s, err := species.Find(utils.DB(g.c), p.Args["name"].(string))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return *s, nil
+}
+
+// Favorite relations, resolved through the loaders
+
+func resolveFavoriteTags(p graphql.ResolveParams) (interface{}, error) {
+	return graphqlFrom(p).tags.Load(p.Source.(models.Pokemon).ID), nil
+}
+
+// resolveFavoriteOwner answers null unless the caller owns the favorite or
+// is an admin
+func resolveFavoriteOwner(p graphql.ResolveParams) (interface{}, error) {
+	ctx, pokemon := graphqlFrom(p), p.Source.(models.Pokemon)
+	if !canEditFavorite(ctx.c.GetString("email"), ctx.c.GetString("role"), pokemon) {
+		return nil, nil
+	}
+	return ctx.owners.Load(pokemon.UserEmail), nil
+}
+
+func resolveFavoriteSpecies(p graphql.ResolveParams) (interface{}, error) {
+	name := strings.ToLower(strings.TrimSpace(p.Source.(models.Pokemon).Name))
+	return graphqlFrom(p).species.Load(name), nil
+}
+
+// Mutation resolvers, they share the favorite operations of the REST
+// handlers
+
+func resolveCreateFavorite(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	var req dto.CreateFavoritePokemonRequest
+	if err := graphqlDecode(p.Args["input"], &req); err != nil {
+		return nil, err
+	}
+	pokemon, err := createFavorite(utils.DB(g.c), req)
+	if err != nil {
+		return nil, err
+	}
+	return pokemon, nil
+}
+
+func resolveUpdateFavorite(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	pokemon, err := graphqlFavoriteVersion(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// the input is merged on the current state
+	doc := favoriteDocument(pokemon)
+	if err := graphqlDecode(p.Args["input"], &doc); err != nil {
+		return nil, err
+	}
+	if err := updateFavorite(utils.DB(g.c), &pokemon, doc); err != nil {
+		return nil, err
+	}
+	return pokemon, nil
+}
+
+func resolveDeleteFavorite(p graphql.ResolveParams) (interface{}, error) {
+	g := graphqlFrom(p)
+	pokemon, err := graphqlFavoriteVersion(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := deleteFavorite(utils.DB(g.c), pokemon); err != nil {
+		return nil, err
+	}
+	return pokemon, nil
+}
+
+// graphqlFavoriteVersion fetches the favorite of a mutation and checks the
+// expected version, the GraphQL counterpart of If-Match
+func graphqlFavoriteVersion(p graphql.ResolveParams) (models.Pokemon, error) {
+	g := graphqlFrom(p)
+	pokemon, err := findFavorite(utils.DB(g.c), graphqlID(p))
+	if err != nil {
+		return pokemon, err
+	}
+	version, _ := p.Args["version"].(int)
+	return pokemon, expectVersion(pokemon, uint(version))
+}
+
+// graphqlID parses the id argument, ids that are not numbers match nothing
+func graphqlID(p graphql.ResolveParams) uint {
+	id, _ := strconv.ParseUint(fmt.Sprint(p.Args["id"]), 10, 64)
+	return uint(id)
+}
+
+// graphqlDecode copies an input object into a request and applies its
+// binding rules
+func graphqlDecode(input interface{}, req interface{}) error {
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(encoded, req); err != nil {
+		return err
+	}
+	return validateRequest(req)
+}
+
+// graphqlFavorites lists favorites with the GetPokemons filters
+func graphqlFavorites(p graphql.ResolveParams, db *gorm.DB) (interface{}, error) {
+	query, err := graphqlListQuery(p.Args, pokemonSortFields)
+	if err != nil {
+		return nil, err
+	}
+	if tags, ok := p.Args["tag"].([]interface{}); ok {
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = fmt.Sprint(tag)
+		}
+		query.Set("tag", strings.Join(names, ","))
+	}
+	if boxID, ok := p.Args["boxId"]; ok && boxID != nil {
+		query.Set("box_id", fmt.Sprint(boxID))
+	}
+
+	page, _, err := listFavorites(db, query)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// graphqlListQuery turns the list arguments into the query parameters the
+// REST lists read, so filters and pagination behave the same
+func graphqlListQuery(args map[string]interface{}, sortable map[string]bool) (url.Values, error) {
+	query := url.Values{}
+	if conditions, ok := args["filter"].([]interface{}); ok {
+		for _, condition := range conditions {
+			setFilterCondition(query, "", condition)
+		}
+	}
+	if groups, ok := args["or"].([]interface{}); ok {
+		for i, group := range groups {
+			conditions, _ := group.([]interface{})
+			for _, condition := range conditions {
+				setFilterCondition(query, fmt.Sprintf("or[%d]", i), condition)
+			}
+		}
+	}
+
+	for arg, key := range map[string]string{"page": "page", "limit": "limit", "cursor": "cursor", "paginate": "paginate", "includeTotal": "include_total"} {
+		if value, ok := args[arg]; ok && value != nil {
+			query.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	sortBy, _ := args["sortBy"].(string)
+	order, _ := args["order"].(string)
+	return query, setListSort(query, sortable, sortBy, order)
+}
+
+func setFilterCondition(query url.Values, prefix string, condition interface{}) {
+	fields, _ := condition.(map[string]interface{})
+	field, _ := fields["field"].(string)
+	op, _ := fields["op"].(string)
+	value, _ := fields["value"].(string)
+	addFilterCondition(query, prefix, field, op, value)
+}
+
+// graphqlLimit reads a query limit from the environment
+func graphqlLimit(name string, fallback int) int {
+	limit, err := strconv.Atoi(config.GetEnv(name))
+	if err != nil || limit <= 0 {
+		return fallback // Default limit
+	}
+	return limit
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"go-api/internal/models"
+	"go-api/internal/utils"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// graphqlSchema is the schema served by /graphql, resolvers live in
+// graphql_handler.go
+var graphqlSchema = mustGraphQLSchema()
+
+// graphqlListArgs are the filter and pagination arguments of list fields,
+// they mirror the query parameters of the REST list endpoints
+func graphqlListArgs(extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{
+		"filter":       {Type: graphql.NewList(graphql.NewNonNull(filterInputType)), Description: "Conditions that must all match, like field[op]=value"},
+		"or":           {Type: graphql.NewList(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(filterInputType)))), Description: "Groups of conditions of which one must match, like or[group][field][op]=value"},
+		"page":         {Type: graphql.Int, DefaultValue: 1},
+		"limit":        {Type: graphql.Int, DefaultValue: 10},
+		"sortBy":       {Type: graphql.String},
+		"order":        {Type: graphql.String, Description: "asc or desc"},
+		"paginate":     {Type: graphql.String, Description: "Set to cursor for keyset pagination"},
+		"cursor":       {Type: graphql.String, Description: "Cursor from nextCursor or prevCursor"},
+		"includeTotal": {Type: graphql.Boolean},
+	}
+	for name, arg := range extra {
+		args[name] = arg
+	}
+	return args
+}
+
+var filterInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "FilterInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"field": {Type: graphql.NewNonNull(graphql.String)},
+		"op":    {Type: graphql.String, DefaultValue: utils.OpEq, Description: "eq, ne, like, lt, lte, gt, gte, in, nin or null"},
+		"value": {Type: graphql.String, DefaultValue: ""},
+	},
+})
+
+var tagType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Tag",
+	Fields: graphql.Fields{
+		"id":   {Type: graphql.NewNonNull(graphql.ID)},
+		"name": {Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var ownerType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Owner",
+	Fields: graphql.Fields{
+		"id":    {Type: graphql.NewNonNull(graphql.ID)},
+		"name":  {Type: graphql.NewNonNull(graphql.String)},
+		"email": {Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var speciesType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Species",
+	Fields: graphql.Fields{
+		"id":   {Type: graphql.NewNonNull(graphql.Int), Description: "National dex number"},
+		"name": {Type: graphql.NewNonNull(graphql.String)},
+		"types": {Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return splitList(p.Source.(models.Species).Types, "/"), nil
+		}},
+		"forms": {Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return splitList(p.Source.(models.Species).Forms, ","), nil
+		}},
+		"genderless":        {Type: graphql.NewNonNull(graphql.Boolean)},
+		"genderDifferences": {Type: graphql.NewNonNull(graphql.Boolean)},
+		"gigantamax":        {Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+var favoriteType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Favorite",
+	Fields: graphql.Fields{
+		"id": {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(models.Pokemon).ID, nil
+		}},
+		"createdAt": {Type: graphql.DateTime, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(models.Pokemon).CreatedAt, nil
+		}},
+		"updatedAt": {Type: graphql.DateTime, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(models.Pokemon).UpdatedAt, nil
+		}},
+		"userEmail": {Type: graphql.NewNonNull(graphql.String)},
+		"name":      {Type: graphql.NewNonNull(graphql.String)},
+		"type":      {Type: graphql.NewNonNull(graphql.String)},
+		"notes":     {Type: graphql.NewNonNull(graphql.String)},
+		"sprite":    {Type: graphql.NewNonNull(graphql.String)},
+		"shiny":     {Type: graphql.NewNonNull(graphql.Boolean)},
+		"form":      {Type: graphql.NewNonNull(graphql.String)},
+		"gender":    {Type: graphql.NewNonNull(graphql.String)},
+		"version":   {Type: graphql.NewNonNull(graphql.Int)},
+		"tags":      {Type: graphql.NewList(graphql.NewNonNull(tagType)), Resolve: resolveFavoriteTags},
+		"owner":     {Type: ownerType, Resolve: resolveFavoriteOwner},
+		"species":   {Type: speciesType, Resolve: resolveFavoriteSpecies},
+	},
+})
+
+var favoriteConnectionType = graphqlConnection("FavoriteConnection", favoriteType)
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id": {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(models.User).ID, nil
+		}},
+		"createdAt": {Type: graphql.DateTime, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(models.User).CreatedAt, nil
+		}},
+		"updatedAt": {Type: graphql.DateTime, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(models.User).UpdatedAt, nil
+		}},
+		"name":    {Type: graphql.NewNonNull(graphql.String)},
+		"email":   {Type: graphql.NewNonNull(graphql.String)},
+		"role":    {Type: graphql.NewNonNull(graphql.String)},
+		"version": {Type: graphql.NewNonNull(graphql.Int)},
+		"favorites": {
+			Type:    favoriteConnectionType,
+			Args:    graphqlListArgs(favoriteListArgs),
+			Resolve: resolveUserFavorites,
+		},
+	},
+})
+
+var userConnectionType = graphqlConnection("UserConnection", userType)
+
+// favoriteListArgs are the favorite filters that are not columns
+var favoriteListArgs = graphql.FieldConfigArgument{
+	"tag":   {Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Tags that must all match"},
+	"boxId": {Type: graphql.ID},
+}
+
+// graphqlConnection is a page of items, it resolves from utils.DataResponse
+func graphqlConnection(name string, item *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"items":           {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(item)))},
+			"currentPage":     {Type: graphql.NewNonNull(graphql.Int)},
+			"totalPages":      {Type: graphql.NewNonNull(graphql.Int)},
+			"totalItems":      {Type: graphql.NewNonNull(graphql.Int)},
+			"limit":           {Type: graphql.NewNonNull(graphql.Int)},
+			"hasNextPage":     {Type: graphql.NewNonNull(graphql.Boolean)},
+			"hasPreviousPage": {Type: graphql.NewNonNull(graphql.Boolean)},
+			"nextCursor":      {Type: graphql.String},
+			"prevCursor":      {Type: graphql.String},
+		},
+	})
+}
+
+var favoriteInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "FavoriteInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":      {Type: graphql.NewNonNull(graphql.String)},
+		"type":      {Type: graphql.String},
+		"notes":     {Type: graphql.NewNonNull(graphql.String)},
+		"sprite":    {Type: graphql.String},
+		"shiny":     {Type: graphql.Boolean},
+		"form":      {Type: graphql.String},
+		"gender":    {Type: graphql.String},
+		"userEmail": {Type: graphql.NewNonNull(graphql.String)},
+		"tags":      {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+	},
+})
+
+var favoriteUpdateInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "FavoriteUpdateInput",
+	Description: "Fields to change, the others keep their value",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":   {Type: graphql.String},
+		"type":   {Type: graphql.String},
+		"notes":  {Type: graphql.String},
+		"shiny":  {Type: graphql.Boolean},
+		"form":   {Type: graphql.String},
+		"gender": {Type: graphql.String},
+	},
+})
+
+func mustGraphQLSchema() graphql.Schema {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {Type: userType, Resolve: resolveMe},
+			"user": {
+				Type:        userType,
+				Description: "Admin only",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     resolveUser,
+			},
+			"users": {
+				Type:        userConnectionType,
+				Description: "Admin only",
+				Args:        graphqlListArgs(nil),
+				Resolve:     resolveUsers,
+			},
+			"favorite": {
+				Type:    favoriteType,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: resolveFavorite,
+			},
+			"favorites": {
+				Type:    favoriteConnectionType,
+				Args:    graphqlListArgs(favoriteListArgs),
+				Resolve: resolveFavorites,
+			},
+			"species": {
+				Type:    speciesType,
+				Args:    graphql.FieldConfigArgument{"name": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: resolveSpecies,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createFavorite": {
+				Type:    favoriteType,
+				Args:    graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(favoriteInputType)}},
+				Resolve: resolveCreateFavorite,
+			},
+			"updateFavorite": {
+				Type: favoriteType,
+				Args: graphql.FieldConfigArgument{
+					"id":      {Type: graphql.NewNonNull(graphql.ID)},
+					"input":   {Type: graphql.NewNonNull(favoriteUpdateInputType)},
+					"version": {Type: graphql.Int, Description: "Expected version, like If-Match"},
+				},
+				Resolve: resolveUpdateFavorite,
+			},
+			"deleteFavorite": {
+				Type: favoriteType,
+				Args: graphql.FieldConfigArgument{
+					"id":      {Type: graphql.NewNonNull(graphql.ID)},
+					"version": {Type: graphql.Int, Description: "Expected version, like If-Match"},
+				},
+				Resolve: resolveDeleteFavorite,
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func splitList(value, separator string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/internal/auth"
+	"go-api/internal/dto"
+	"go-api/internal/middleware"
+	"go-api/internal/models"
+	"go-api/internal/rpc/pb"
+	"go-api/internal/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// UserGRPCServer serves pb.UserService
+type UserGRPCServer struct {
+	pb.UnimplementedUserServiceServer
+}
+
+// FavoriteGRPCServer serves pb.FavoriteService with the favorite operations
+// of the REST handlers
+type FavoriteGRPCServer struct {
+	pb.UnimplementedFavoriteServiceServer
+}
+
+// GetUser looks a user up by id or email, other users than the caller are
+// admin only
+func (UserGRPCServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	claims := auth.ClaimsFrom(ctx)
+	db := grpcDB(ctx)
+
+	var user models.User
+	var err error
+	switch lookup := req.Lookup.(type) {
+	case *pb.GetUserRequest_Id:
+		err = db.First(&user, lookup.Id).Error
+	case *pb.GetUserRequest_Email:
+		err = db.Where("email = ?", lookup.Email).First(&user).Error
+	default:
+		return nil, status.Error(codes.InvalidArgument, "id or email is required")
+	}
+
+	if err == nil && user.Email == claims.Email {
+		return userMessage(user), nil
+	}
+
+	// only admins learn whether another user exists
+	if !middleware.HasRole(claims.Role, "admin") {
+		return nil, status.Error(codes.PermissionDenied, "Forbidden: Insufficient permissions")
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "User not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to fetch user")
+	}
+	return userMessage(user), nil
+}
+
+func (FavoriteGRPCServer) GetFavorite(ctx context.Context, req *pb.GetFavoriteRequest) (*pb.Favorite, error) {
+	db := grpcDB(ctx)
+	pokemon, err := findFavorite(db, grpcID(req.Id))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return favoriteMessage(db, pokemon)
+}
+
+func (FavoriteGRPCServer) ListFavorites(ctx context.Context, req *pb.ListFavoritesRequest) (*pb.ListFavoritesResponse, error) {
+	query, err := grpcListQuery(req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	db := grpcDB(ctx)
+	page, pokemons, err := listFavorites(db, query)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	items, err := favoriteMessages(db, pokemons)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ListFavoritesResponse{
+		Items:           items,
+		CurrentPage:     int32(page.CurrentPage),
+		TotalPages:      int32(page.TotalPages),
+		TotalItems:      page.TotalItems,
+		Limit:           int32(page.Limit),
+		HasNextPage:     page.HasNextPage,
+		HasPreviousPage: page.HasPreviousPage,
+		NextCursor:      page.NextCursor,
+		PrevCursor:      page.PrevCursor,
+	}, nil
+}
+
+func (FavoriteGRPCServer) CreateFavorite(ctx context.Context, req *pb.CreateFavoriteRequest) (*pb.Favorite, error) {
+	create := dto.CreateFavoritePokemonRequest{
+		Name:      req.Name,
+		Type:      req.Type,
+		Notes:     req.Notes,
+		Sprite:    req.Sprite,
+		Shiny:     req.Shiny,
+		Form:      req.Form,
+		Gender:    req.Gender,
+		UserEmail: req.UserEmail,
+		Tags:      req.Tags,
+	}
+	if err := validateRequest(&create); err != nil {
+		return nil, grpcError(err)
+	}
+
+	db := grpcDB(ctx)
+	pokemon, err := createFavorite(db, create)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return favoriteMessage(db, pokemon)
+}
+
+func (FavoriteGRPCServer) UpdateFavorite(ctx context.Context, req *pb.UpdateFavoriteRequest) (*pb.Favorite, error) {
+	db := grpcDB(ctx)
+	pokemon, err := findFavorite(db, grpcID(req.Id))
+	if err == nil {
+		err = expectVersion(pokemon, uint(req.Version))
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	// the fields that are set are merged on the current state
+	doc := favoriteDocument(pokemon)
+	if req.Name != nil {
+		doc.Name = *req.Name
+	}
+	if req.Type != nil {
+		doc.Type = *req.Type
+	}
+	if req.Notes != nil {
+		doc.Notes = *req.Notes
+	}
+	if req.Shiny != nil {
+		doc.Shiny = *req.Shiny
+	}
+	if req.Form != nil {
+		doc.Form = *req.Form
+	}
+	if req.Gender != nil {
+		doc.Gender = *req.Gender
+	}
+	if err := validateRequest(&doc); err != nil {
+		return nil, grpcError(err)
+	}
+
+	if err := updateFavorite(db, &pokemon, doc); err != nil {
+		return nil, grpcError(err)
+	}
+	return favoriteMessage(db, pokemon)
+}
+
+func (FavoriteGRPCServer) DeleteFavorite(ctx context.Context, req *pb.DeleteFavoriteRequest) (*pb.Favorite, error) {
+	db := grpcDB(ctx)
+	pokemon, err := findFavorite(db, grpcID(req.Id))
+	if err == nil {
+		err = expectVersion(pokemon, uint(req.Version))
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	// the tags are read before they go away with the favorite
+	message, err := favoriteMessage(db, pokemon)
+	if err != nil {
+		return nil, err
+	}
+	if err := deleteFavorite(db, pokemon); err != nil {
+		return nil, grpcError(err)
+	}
+	return message, nil
+}
+
+// grpcDB returns the database bound to the context of the call
+func grpcDB(ctx context.Context) *gorm.DB {
+	return config.DB.WithContext(ctx)
+}
+
+// grpcID converts a message id, ids out of range match nothing
+func grpcID(id uint64) uint {
+	if uint64(uint(id)) != id {
+		return 0
+	}
+	return uint(id)
+}
+
+// grpcCodes maps the statuses of the shared operations to gRPC codes
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnprocessableEntity: codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+}
+
+// grpcError is utils.ErrorResponse for gRPC
+func grpcError(err error) error {
+	var statusErr *utils.StatusError
+	if !errors.As(err, &statusErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code, ok := grpcCodes[statusErr.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	return status.Error(code, statusErr.Message)
+}
+
+// grpcListQuery turns a list request into the query parameters the REST
+// lists read, so filters and pagination behave the same
+func grpcListQuery(req *pb.ListFavoritesRequest) (url.Values, error) {
+	query := url.Values{}
+	for _, filter := range req.Filters {
+		addFilterCondition(query, "", filter.Field, filter.Op, filter.Value)
+	}
+	for i, group := range req.Or {
+		for _, filter := range group.Filters {
+			addFilterCondition(query, fmt.Sprintf("or[%d]", i), filter.Field, filter.Op, filter.Value)
+		}
+	}
+	if len(req.Tags) > 0 {
+		query.Set("tag", strings.Join(req.Tags, ","))
+	}
+	if req.BoxId != 0 {
+		query.Set("box_id", strconv.FormatUint(req.BoxId, 10))
+	}
+
+	if req.Page > 0 {
+		query.Set("page", strconv.Itoa(int(req.Page)))
+	}
+	if req.Limit > 0 {
+		query.Set("limit", strconv.Itoa(int(req.Limit)))
+	}
+	if req.Cursor != "" {
+		query.Set("cursor", req.Cursor)
+	}
+	if req.PaginateCursor {
+		query.Set("paginate", "cursor")
+	}
+	if req.IncludeTotal != nil {
+		query.Set("include_total", strconv.FormatBool(*req.IncludeTotal))
+	}
+	return query, setListSort(query, pokemonSortFields, req.SortBy, req.Order)
+}
+
+func userMessage(user models.User) *pb.User {
+	return &pb.User{
+		Id:        uint64(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		Version:   uint32(user.Version),
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
+
+func favoriteMessage(db *gorm.DB, pokemon models.Pokemon) (*pb.Favorite, error) {
+	messages, err := favoriteMessages(db, []models.Pokemon{pokemon})
+	if err != nil {
+		return nil, err
+	}
+	return messages[0], nil
+}
+
+// favoriteMessages converts favorites, their tags are loaded in one query
+func favoriteMessages(db *gorm.DB, pokemons []models.Pokemon) ([]*pb.Favorite, error) {
+	ids := make([]uint, len(pokemons))
+	for i, pokemon := range pokemons {
+		ids[i] = pokemon.ID
+	}
+	tags := map[uint][]favoriteTag{}
+	if len(ids) > 0 {
+		var err error
+		if tags, err = favoriteTags(db, ids); err != nil {
+			return nil, status.Error(codes.Internal, "Failed to load tags")
+		}
+	}
+
+	messages := make([]*pb.Favorite, len(pokemons))
+	for i, pokemon := range pokemons {
+		message := &pb.Favorite{
+			Id:        uint64(pokemon.ID),
+			UserEmail: pokemon.UserEmail,
+			Name:      pokemon.Name,
+			Type:      pokemon.Type,
+			Notes:     pokemon.Notes,
+			Sprite:    pokemon.Sprite,
+			Shiny:     pokemon.Shiny,
+			Form:      pokemon.Form,
+			Gender:    pokemon.Gender,
+			Version:   uint32(pokemon.Version),
+			Tags:      []*pb.Tag{},
+			CreatedAt: timestamppb.New(pokemon.CreatedAt),
+			UpdatedAt: timestamppb.New(pokemon.UpdatedAt),
+		}
+		for _, tag := range tags[pokemon.ID] {
+			message.Tags = append(message.Tags, &pb.Tag{Id: uint64(tag.ID), Name: tag.Name})
+		}
+		messages[i] = message
+	}
+	return messages, nil
+}
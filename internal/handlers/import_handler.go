@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-api/internal/dto"
+	"go-api/internal/events"
+	"go-api/internal/models"
+	"go-api/internal/outbox"
+	"go-api/internal/utils"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// Import modes
+const (
+	importAllOrNothing = "all_or_nothing"
+	importBestEffort   = "best_effort"
+)
+
+const (
+	importBatchSize = 100
+	importMaxRows   = 5000
+	importMaxBytes  = 5 << 20
+)
+
+// importInput is a row read from the file, errors holds what could not be
+// parsed (e.g. a shiny flag that is not a boolean)
+type importInput struct {
+	req    dto.CreateFavoritePokemonRequest
+	errors []string
+}
+
+// importRow is a validated row waiting to be inserted
+type importRow struct {
+	result  *dto.ImportRowResult
+	pokemon models.Pokemon
+}
+
+// Import routes
+// ImportPokemons godoc
+// @Summary      Import pokemons
+// @Description  Create favorites in bulk from a CSV file (header row with the request field names, tags comma separated) or a JSON array. Every row is validated like POST /pokemon/create and reported individually.
+// @Tags         Pokemons
+// @Accept       json
+// @Accept       text/csv
+// @Produce      json
+// @Security     BearerAuth
+// @Param        dry_run  query     bool    false  "Validate only, nothing is written"
+// @Param        mode     query     string  false  "all_or_nothing (default) or best_effort"
+// @Param        Idempotency-Key  header  string  false  "Replay the stored response when the request is retried"
+// @Param        rows     body      []dto.CreateFavoritePokemonRequest  true  "Rows to import"
+// @Success      200 {object} utils.BaseResponse "Dry run or best effort report"
+// @Success      201 {object} utils.BaseResponse "Every row was created"
+// @Failure      400 {object} utils.BaseResponse "Unreadable file or invalid rows"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      413 {object} utils.BaseResponse "Too many rows"
+// @Failure      415 {object} utils.BaseResponse "Unsupported media type"
+// @Router       /pokemon/import [post]
+func ImportPokemons(c *gin.Context) {
+	mode := c.DefaultQuery("mode", importAllOrNothing)
+	if mode != importAllOrNothing && mode != importBestEffort {
+		utils.Response(c, http.StatusBadRequest, false, "mode must be all_or_nothing or best_effort", nil)
+		return
+	}
+	report := dto.ImportReport{DryRun: c.Query("dry_run") == "true", Mode: mode}
+
+	// read the rows
+	inputs, err := readImportRows(c)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		utils.Response(c, http.StatusRequestEntityTooLarge, false, fmt.Sprintf("Import must be smaller than %d bytes", importMaxBytes), nil)
+		return
+	case errors.Is(err, errTooManyRows):
+		utils.Response(c, http.StatusRequestEntityTooLarge, false, err.Error(), nil)
+		return
+	case errors.Is(err, errUnsupportedImport):
+		utils.Response(c, http.StatusUnsupportedMediaType, false, err.Error(), nil)
+		return
+	case err != nil:
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	// validate every row
+	report.Total = len(inputs)
+	report.Rows = make([]dto.ImportRowResult, len(inputs))
+	var rows []importRow
+	for i, input := range inputs {
+		result := &report.Rows[i]
+		result.Row = i + 1
+		pokemon, problems := validateImportRow(input)
+		if len(problems) > 0 {
+			result.Status = dto.ImportRowInvalid
+			result.Errors = problems
+			report.Invalid++
+			continue
+		}
+		result.Status = dto.ImportRowValid
+		report.Valid++
+		rows = append(rows, importRow{result: result, pokemon: pokemon})
+	}
+
+	if report.DryRun {
+		utils.Response(c, http.StatusOK, report.Invalid == 0, "Import validated", report)
+		return
+	}
+
+	// all or nothing refuses the whole file when a row is invalid
+	if mode == importAllOrNothing && report.Invalid > 0 {
+		for _, row := range rows {
+			row.result.Status = dto.ImportRowSkipped
+		}
+		code := http.StatusBadRequest
+		if utils.APIVersion(c) >= 2 {
+			code = http.StatusUnprocessableEntity
+		}
+		utils.Response(c, code, false, "Import has invalid rows, nothing was created", report)
+		return
+	}
+
+	// insert in batched transactions
+	var created []models.Pokemon
+	if mode == importAllOrNothing {
+		err := utils.DB(c).Transaction(func(tx *gorm.DB) error {
+			for start := 0; start < len(rows); start += importBatchSize {
+				if err := insertImportBatch(tx, rows[start:min(start+importBatchSize, len(rows))]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			for _, row := range rows {
+				row.result.Status = dto.ImportRowSkipped
+				row.result.ID = 0
+			}
+			utils.Response(c, http.StatusConflict, false, "Import failed, nothing was created: "+err.Error(), report)
+			return
+		}
+		for _, row := range rows {
+			row.result.Status = dto.ImportRowCreated
+			created = append(created, row.pokemon)
+		}
+	} else {
+		for start := 0; start < len(rows); start += importBatchSize {
+			created = append(created, insertImportBatchBestEffort(utils.DB(c), rows[start:min(start+importBatchSize, len(rows))])...)
+		}
+	}
+	report.Created = len(created)
+	report.Failed = report.Valid - report.Created
+	outbox.Notify()
+
+	if report.Created == report.Total {
+		utils.Response(c, http.StatusCreated, true, "Import completed", report)
+		return
+	}
+	utils.Response(c, http.StatusOK, report.Created > 0, "Import completed with errors", report)
+}
+
+var (
+	errUnsupportedImport = errors.New("Content-Type must be text/csv or application/json")
+	errTooManyRows       = fmt.Errorf("Import is limited to %d rows", importMaxRows)
+)
+
+// readImportRows decodes the body as a JSON array or a CSV file
+func readImportRows(c *gin.Context) ([]importInput, error) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, importMaxBytes)
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch mediaType {
+	case "application/json":
+		var requests []dto.CreateFavoritePokemonRequest
+		if err := json.NewDecoder(body).Decode(&requests); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("body must be a JSON array of pokemons: %w", err)
+		}
+		if len(requests) > importMaxRows {
+			return nil, errTooManyRows
+		}
+		inputs := make([]importInput, len(requests))
+		for i, req := range requests {
+			inputs[i].req = req
+		}
+		return inputs, nil
+	case "text/csv":
+		return readImportCSV(body)
+	}
+	return nil, errUnsupportedImport
+}
+
+// readImportCSV maps the columns by their header, tags are comma separated
+func readImportCSV(body io.Reader) ([]importInput, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", ""))
+		switch key {
+		case "name", "type", "notes", "sprite", "shiny", "form", "gender", "useremail", "tags":
+			columns[key] = i
+		default:
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+	}
+
+	var inputs []importInput
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return inputs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(inputs) >= importMaxRows {
+			return nil, errTooManyRows
+		}
+		get := func(key string) string {
+			if i, ok := columns[key]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		var input importInput
+		input.req = dto.CreateFavoritePokemonRequest{
+			Name:      get("name"),
+			Type:      get("type"),
+			Notes:     get("notes"),
+			Sprite:    get("sprite"),
+			Form:      get("form"),
+			Gender:    get("gender"),
+			UserEmail: get("useremail"),
+		}
+		if shiny := get("shiny"); shiny != "" {
+			input.req.Shiny, err = strconv.ParseBool(shiny)
+			if err != nil {
+				input.errors = append(input.errors, fmt.Sprintf("shiny: %q is not a boolean", shiny))
+			}
+		}
+		for _, tag := range strings.Split(get("tags"), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				input.req.Tags = append(input.req.Tags, tag)
+			}
+		}
+		inputs = append(inputs, input)
+	}
+}
+
+// validateImportRow applies the create binding rules and the species catalog
+func validateImportRow(input importInput) (models.Pokemon, []string) {
+	if len(input.errors) > 0 {
+		return models.Pokemon{}, input.errors
+	}
+	req := input.req
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return models.Pokemon{}, utils.ValidationMessages(err)
+	}
+
+	pokemon := models.Pokemon{
+		Name:      req.Name,
+		Type:      req.Type,
+		Notes:     req.Notes,
+		Sprite:    req.Sprite,
+		Shiny:     req.Shiny,
+		Form:      req.Form,
+		Gender:    req.Gender,
+		UserEmail: req.UserEmail,
+	}
+	if err := applyVariant(&pokemon); err != nil {
+		return models.Pokemon{}, []string{err.Error()}
+	}
+	for _, tag := range req.Tags {
+		pokemon.Tags = append(pokemon.Tags, models.Tag{UserEmail: req.UserEmail, Name: tag})
+	}
+	return pokemon, nil
+}
+
+// insertImportBatch creates the rows with one batched insert
+func insertImportBatch(tx *gorm.DB, rows []importRow) error {
+	pokemons := make([]models.Pokemon, len(rows))
+	for i, row := range rows {
+		pokemon := row.pokemon
+		names := make([]string, len(pokemon.Tags))
+		for j, tag := range pokemon.Tags {
+			names[j] = tag.Name
+		}
+		tags, err := findOrCreateTags(tx, pokemon.UserEmail, names)
+		if err != nil {
+			return err
+		}
+		pokemon.Tags = tags
+		pokemons[i] = pokemon
+	}
+	if err := tx.Create(&pokemons).Error; err != nil {
+		return err
+	}
+	for i := range rows {
+		rows[i].pokemon = pokemons[i]
+		rows[i].result.ID = pokemons[i].ID
+		event := events.Event{Type: events.PokemonCreated, UserEmail: pokemons[i].UserEmail, Payload: pokemons[i]}
+		if err := outbox.Record(tx, outbox.Pokemon, pokemons[i].ID, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertImportBatchBestEffort commits the batch in one transaction, when
+// it fails the rows are retried one by one to find the failing ones
+func insertImportBatchBestEffort(db *gorm.DB, rows []importRow) []models.Pokemon {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return insertImportBatch(tx, rows)
+	})
+	if err == nil {
+		created := make([]models.Pokemon, len(rows))
+		for i, row := range rows {
+			row.result.Status = dto.ImportRowCreated
+			created[i] = row.pokemon
+		}
+		return created
+	}
+
+	var created []models.Pokemon
+	for i := range rows {
+		row := rows[i : i+1]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return insertImportBatch(tx, row)
+		})
+		if err != nil {
+			row[0].result.Status = dto.ImportRowFailed
+			row[0].result.ID = 0
+			row[0].result.Errors = []string{err.Error()}
+			continue
+		}
+		row[0].result.Status = dto.ImportRowCreated
+		created = append(created, row[0].pokemon)
+	}
+	return created
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"go-api/internal/dto"
+	"go-api/internal/models"
+	"go-api/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Log routes
+// GetLogs godoc
+// @Summary      Get request logs
+// @Description  Explore the logged requests, newest first with keyset pagination. Fields also accept operators: field[eq|ne|like|lt|lte|gt|gte|in|nin|null]=value, e.g. status_code[gte]=500 or created_at[gte]=2026-10-01T00:00:00Z. The bodies are left out, see GET /log
+// @Tags         Logs
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        method       query  string  false  "Filter by method, e.g. method[in]=POST,PUT"
+// @Param        status       query  string  false  "Filter by status, exact (404) or class (5xx)"
+// @Param        status_code  query  int     false  "Filter by status code, e.g. status_code[gte]=400"
+// @Param        path         query  string  false  "Filter by path prefix, e.g. /api/v2/pokemons"
+// @Param        route        query  string  false  "Filter by route template, e.g. /api/v2/pokemons/:id"
+// @Param        user_email   query  string  false  "Filter by user"
+// @Param        client_ip    query  string  false  "Filter by client IP"
+// @Param        request_id   query  string  false  "Filter by correlation id (X-Request-ID)"
+// @Param        created_at   query  string  false  "Filter by time, e.g. created_at[gte]=2026-10-01&created_at[lt]=2026-10-02"
+// @Param        q            query  string  false  "Full-text search over the request and response bodies"
+// @Param        limit        query  int     false  "Number of items per page"
+// @Param        cursor       query  string  false  "Cursor from nextCursor or prevCursor"
+// @Param        sort         query  string  false  "Sort, e.g. duration_ms desc (default id desc)"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Invalid filter or cursor"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Router       /logs [get]
+func GetLogs(c *gin.Context) {
+	db, err := utils.ApplyFilters(c, utils.DB(c).Model(&models.Log{}), logFilterFields)
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	query := c.Request.URL.Query()
+	if status := query.Get("status"); status != "" {
+		min, max, ok := statusRange(status)
+		if !ok {
+			utils.Response(c, http.StatusBadRequest, false, "status must be a code such as 404 or a class such as 5xx", nil)
+			return
+		}
+		db = db.Where("status_code BETWEEN ? AND ?", min, max)
+	}
+	if path := query.Get("path"); path != "" {
+		db = db.Where(`uri LIKE ? ESCAPE '\'`, likeEscaper.Replace(path)+"%")
+	}
+	if text := strings.TrimSpace(query.Get("q")); text != "" {
+		db = searchLogBodies(db, text)
+	}
+
+	// the log is too large to count, always page by keyset, newest first
+	// unless sorted otherwise
+	query.Set("paginate", "cursor")
+	var logs []models.Log
+	page, err := fetchPage(db.Omit("request_body", "response_body"), query, logSortFields, &logs)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	// build the reponse
+	summaries := make([]dto.LogSummary, len(logs))
+	for i, entry := range logs {
+		summaries[i] = dto.LogSummary{
+			ID:         entry.ID,
+			RequestID:  entry.RequestID,
+			UserEmail:  entry.UserEmail,
+			Method:     entry.Method,
+			URI:        entry.URI,
+			Route:      entry.Route,
+			ClientIP:   entry.ClientIP,
+			StatusCode: entry.StatusCode,
+			DurationMs: entry.DurationMs,
+			CreatedAt:  entry.CreatedAt,
+		}
+	}
+	page.Items = summaries
+	utils.Response(c, http.StatusOK, true, "Succes fetching logs", page)
+}
+
+// logFilterFields are the columns GetLogs filters on
+var logFilterFields = map[string]string{
+	"method":      "exact",
+	"status_code": "int",
+	"route":       "exact",
+	"user_email":  "exact",
+	"client_ip":   "exact",
+	"request_id":  "exact",
+	"duration_ms": "float",
+	"created_at":  "date",
+}
+
+// logSortFields are the columns GetLogs sorts on
+var logSortFields = map[string]bool{"id": true, "created_at": true, "status_code": true, "duration_ms": true}
+
+// GetLog godoc
+// @Summary      Get request log
+// @Description  Get a logged request with its bodies, by id or by correlation id (X-Request-ID). Passwords, tokens and secrets were redacted from the bodies before they were stored
+// @Tags         Logs
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          query     integer  false  "id"
+// @Param        request_id  query     string   false  "Correlation id"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Log not found"
+// @Router       /log [get]
+func GetLog(c *gin.Context) {
+	var entry models.Log
+	db := utils.DB(c)
+
+	found := false
+	if requestID := c.Query("request_id"); requestID != "" {
+		found = db.Where("request_id = ?", requestID).Order("id DESC").First(&entry).Error == nil
+	} else if id, ok := utils.ResourceID(c); ok {
+		found = db.First(&entry, id).Error == nil
+	}
+	if !found {
+		utils.Response(c, http.StatusNotFound, false, "Log not found", nil)
+		return
+	}
+
+	utils.Response(c, http.StatusOK, true, "Succes fetching log", entry)
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// searchLogBodies keeps the entries whose bodies match the text, through the
+// full-text index on Postgres (web search syntax: "quoted phrase", or, -not)
+// and a plain substring match elsewhere
+func searchLogBodies(db *gorm.DB, text string) *gorm.DB {
+	if db.Dialector.Name() == "postgres" {
+		return db.Where(models.LogSearchVector+" @@ websearch_to_tsquery('simple', ?)", text)
+	}
+	pattern := "%" + likeEscaper.Replace(text) + "%"
+	return db.Where(`(request_body LIKE ? ESCAPE '\' OR response_body LIKE ? ESCAPE '\')`, pattern, pattern)
+}
+
+// statusRange reads "404" or "4xx"
+func statusRange(status string) (int, int, bool) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if len(status) == 3 && strings.HasSuffix(status, "xx") && status[0] >= '1' && status[0] <= '5' {
+		class := int(status[0]-'0') * 100
+		return class, class + 99, true
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, false
+	}
+	return code, code, true
+}
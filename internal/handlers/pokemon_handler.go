@@ -4,11 +4,15 @@ import (
 	"go-api/config"
 	"go-api/internal/dto"
 	"go-api/internal/models"
+	"go-api/internal/species"
 	"go-api/internal/utils"
 	"net/http"
+	"net/url"
 	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Get all pokemons
@@ -16,7 +20,7 @@ import (
 // Pokemon routes
 // GetPokemons godoc
 // @Summary      Get all pokemons
-// @Description  Get list of pokemons with filters
+// @Description  Get list of pokemons with filters. Fields also accept operators: field[eq|ne|like|lt|lte|gt|gte|in|nin|null]=value, and or[group][field][op]=value OR groups
 // @Tags         Pokemons
 // @Accept       json
 // @Produce      json
@@ -25,11 +29,22 @@ import (
 // @Param        name     query     string  false  "Filter by name"
 // @Param        type     query     string  false  "Filter by type"
 // @Param        notes    query     string  false  "Filter by notes"
+// @Param        shiny    query     bool    false  "Filter by shiny"
+// @Param        form     query     string  false  "Filter by form (alolan, galarian, hisuian, paldean, gigantamax, mega)"
+// @Param        gender   query     string  false  "Filter by gender (male, female, genderless)"
+// @Param        tag      query     string  false  "Filter by tags, comma separated, all must match"
+// @Param        box_id   query     int     false  "Filter by box"
 // @Param        sort_by  query     string  false  "Sort by field (name, type, notes)"
 // @Param        order    query     string  false  "Sort order (asc or desc), default is asc"
 // @Param        page     query     int     false  "Page number for pagination"
 // @Param        limit    query     int     false  "Number of items per page"
+// @Param        paginate query     string  false  "Set to cursor for keyset pagination"
+// @Param        cursor   query     string  false  "Cursor from nextCursor or prevCursor"
+// @Param        include_total query bool   false  "Count the total items (default true for offset, false for cursor)"
+// @Param        fields   query     string  false  "Comma separated fields to return, e.g. id,name,sprite"
+// @Param        include  query     string  false  "Comma separated resources to embed (owner, species, tags)"
 // @Success      200    {object}  utils.BaseResponse
+// @Failure      400    {object}  utils.BaseResponse  "Invalid filter, cursor, field or include"
 // @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
 // @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
 // @Failure      404    {object}  utils.BaseResponse  "Pokemons not found"
@@ -39,16 +54,21 @@ func GetPokemons(c *gin.Context) {
 	var pokemons []models.Pokemon
 
 	// fetching data from database
-	db := config.DB
+	db := utils.DB(c)
 
 	// hanlde filter
-	allowedField := map[string]string{
-		"user_email":  "string",
-		"name":  "string",
-		"type": "string",
-		"notes":  "string",
+	db, err := filterPokemons(db, c.Request.URL.Query())
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	// get sparse fields and includes
+	fieldset, includes, err := parsePokemonFieldset(c)
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
 	}
-	db = utils.ApplyFilters(c, db, allowedField)
 
 	// handle sort
 	allowedSortFields := map[string]bool{
@@ -58,7 +78,7 @@ func GetPokemons(c *gin.Context) {
 	}
 	sortBy := c.Query("sort_by")
 	order := c.DefaultQuery("order", "asc")
-	if allowedSortFields[sortBy] {
+	if allowedSortFields[sortBy] && !utils.IsKeysetPagination(c) {
 		if order != "asc" && order != "desc" {
 			order = "asc"
 		}
@@ -66,15 +86,33 @@ func GetPokemons(c *gin.Context) {
 	}
 
 	// get pagination
-	db, pagination := utils.ApplyPagination(c, db, &models.Pokemon{})
+	db, pagination := utils.ApplyPagination(c, db, &models.Pokemon{}, pokemonSortFields)
+	if pagination.Err != nil {
+		utils.Response(c, http.StatusBadRequest, false, pagination.Err.Error(), nil)
+		return
+	}
 
 	// fetch pokemon data
+	db = selectPokemons(db, fieldset, includes, pagination.Columns()...)
 	if err := db.Find(&pokemons).Error; err != nil {
 		utils.Response(c, http.StatusNotFound, false, "Failed to fetch pokemons", nil)
 		return
 	}
 
-	if len(pokemons) == 0 {
+	utils.FinalizePagination(&pagination, &pokemons)
+
+	// project the sparse fields and embed the includes
+	var items interface{} = pokemons
+	if fieldset != nil || len(includes) > 0 {
+		embedded, err := embedPokemons(c, pokemons, fieldset, includes)
+		if err != nil {
+			utils.Response(c, http.StatusInternalServerError, false, "Failed to load included resources", nil)
+			return
+		}
+		items = embedded
+	}
+
+	if len(pokemons) == 0 && utils.APIVersion(c) == 1 {
 		utils.Response(c, http.StatusNotFound, false, "Pokemons not found", nil)
 		return
 	}
@@ -87,7 +125,9 @@ func GetPokemons(c *gin.Context) {
 		Limit:           pagination.Limit,
 		HasNextPage:     pagination.HasNextPage,
 		HasPreviousPage: pagination.HasPreviousPage,
-		Items:           pokemons,
+		NextCursor:      pagination.NextCursor,
+		PrevCursor:      pagination.PrevCursor,
+		Items:           items,
 	}
 	utils.Response(c, http.StatusOK, true, "Succes fetching pokemons data", dataResponse)
 }
@@ -101,21 +141,48 @@ func GetPokemons(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id   query     integer  false  "id"
+// @Param        fields   query     string  false  "Comma separated fields to return, e.g. id,name,sprite"
+// @Param        include  query     string  false  "Comma separated resources to embed (owner, species, tags)"
 // @Success      200    {object}  utils.BaseResponse
+// @Failure      400    {object}  utils.BaseResponse  "Invalid field or include"
 // @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
 // @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
 // @Failure      404    {object}  utils.BaseResponse  "Pokemon not found"
 // @Router 		 /pokemon [get]
 func GetPokemonByID(c *gin.Context) {
-	id := c.Query("id")
+	id, ok := utils.ResourceID(c)
 	var pokemon models.Pokemon
 
+	// get sparse fields and includes
+	fieldset, includes, err := parsePokemonFieldset(c)
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
 	// error handling
-	if err := config.DB.First(&pokemon, id).Error; err != nil {
+	db := selectPokemons(utils.DB(c), fieldset, includes, "version")
+	if err := db.First(&pokemon, id).Error; !ok || err != nil {
 		utils.Response(c, http.StatusNotFound, false, "Pokemon not found", nil)
 		return
 	}
 
+	// answer 304 when the client copy is current
+	if utils.NotModified(c, utils.ETag(pokemon.Version)) {
+		return
+	}
+
+	// project the sparse fields and embed the includes
+	var item interface{} = pokemon
+	if fieldset != nil || len(includes) > 0 {
+		embedded, err := embedPokemons(c, []models.Pokemon{pokemon}, fieldset, includes)
+		if err != nil {
+			utils.Response(c, http.StatusInternalServerError, false, "Failed to load included resources", nil)
+			return
+		}
+		item = embedded[0]
+	}
+
 	// build the reponse
 	dataResponse := utils.DataResponse{
 		CurrentPage:     1,
@@ -124,7 +191,7 @@ func GetPokemonByID(c *gin.Context) {
 		Limit:           1,
 		HasNextPage:     false,
 		HasPreviousPage: false,
-		Items:           pokemon,
+		Items:           item,
 	}
 	utils.Response(c, http.StatusOK, true, "Succes fetching pokemon data", dataResponse)
 }
@@ -138,6 +205,7 @@ func GetPokemonByID(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        credentials body dto.CreateFavoritePokemonRequest true "Create pokemon credentials"
+// @Param        Idempotency-Key  header  string  false  "Replay the stored response when the request is retried"
 // @Success      200 {object} dto.Token
 // @Failure      400 {object} utils.BaseResponse "Validation error"
 // @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
@@ -154,20 +222,14 @@ func CreatePokemon(c *gin.Context) {
 	}
 
 	// create the pokemon
-	pokemon := models.Pokemon{
-		Name:  req.Name,
-		Type: req.Type,
-		Notes:  req.Notes,
-		Sprite:  req.Sprite,
-		UserEmail:  req.UserEmail,
-	}
-
-	// save the pokemon
-	if err := config.DB.Create(&pokemon).Error; err != nil {
-		utils.Response(c, http.StatusConflict, false, "Pokemon email already used", nil)
+	pokemon, err := createFavorite(utils.DB(c), req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
 		return
 	}
 
+	c.Header("ETag", utils.ETag(pokemon.Version))
+	c.Header("Location", fmt.Sprintf("/api/v2/pokemons/%d", pokemon.ID))
 	utils.Response(c, http.StatusCreated, true, "Pokemon created", pokemon)
 }
 
@@ -187,7 +249,7 @@ func CreatePokemon(c *gin.Context) {
 // @Failure      404 {object}  utils.BaseResponse  "Pokemons not found"
 // @Router       /pokemon/update [put]
 func UpdatePokemon(c *gin.Context) {
-	id := c.Query("id")
+	id, ok := utils.ResourceID(c)
 
 	// get the serializer and validate it
 	var req dto.UpdateFavoritePokemonRequest
@@ -197,19 +259,77 @@ func UpdatePokemon(c *gin.Context) {
 	}
 
 	// fetching pokemon data
-	var pokemon = models.Pokemon{}
-	if err := config.DB.First(&pokemon, id).Error; err != nil {
+	pokemon, err := findFavorite(utils.DB(c), id)
+	if !ok || err != nil {
 		utils.Response(c, http.StatusNotFound, false, "Pokemon not found", nil)
 		return
 	}
 
-	// update the pokemon data
-	pokemon.Name = req.Name
-	pokemon.Type = req.Type
-	pokemon.Notes = req.Notes
+	// check the If-Match precondition
+	if !utils.CheckIfMatch(c, utils.ETag(pokemon.Version)) {
+		return
+	}
 
-	// save the pokemon
-	config.DB.Save(&pokemon)
+	// update and save the pokemon
+	if err := updateFavorite(utils.DB(c), &pokemon, req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+	c.Header("ETag", utils.ETag(pokemon.Version))
+	utils.Response(c, http.StatusOK, true, "Pokemon updated", pokemon)
+}
+
+// Patch Pokemon
+// PatchPokemon godoc
+// @Summary      Patch pokemon
+// @Description  Partially update a pokemon with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902)
+// @Tags         Pokemons
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Param        patch body dto.UpdateFavoritePokemonRequest true "Merge patch or JSON patch operations"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object}  utils.BaseResponse  "Invalid patch"
+// @Failure      401 {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      404 {object}  utils.BaseResponse  "Pokemon not found"
+// @Failure      409 {object}  utils.BaseResponse  "Patch test operation failed"
+// @Failure      415 {object}  utils.BaseResponse  "Unsupported patch media type"
+// @Router       /pokemon/update [patch]
+func PatchPokemon(c *gin.Context) {
+	id, ok := utils.ResourceID(c)
+
+	// fetching pokemon data
+	pokemon, err := findFavorite(utils.DB(c), id)
+	if !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Pokemon not found", nil)
+		return
+	}
+
+	// check the If-Match precondition
+	if !utils.CheckIfMatch(c, utils.ETag(pokemon.Version)) {
+		return
+	}
+
+	// apply the patch on the current state
+	doc := favoriteDocument(pokemon)
+	changed, err := utils.ApplyPatch(c, &doc)
+	if err != nil {
+		utils.PatchErrorResponse(c, err)
+		return
+	}
+	if len(changed) == 0 {
+		utils.Response(c, http.StatusOK, true, "Pokemon unchanged", pokemon)
+		return
+	}
+
+	// persist only the changed columns
+	if err := updateFavorite(utils.DB(c), &pokemon, doc, changed...); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+	c.Header("ETag", utils.ETag(pokemon.Version))
 	utils.Response(c, http.StatusOK, true, "Pokemon updated", pokemon)
 }
 
@@ -228,14 +348,192 @@ func UpdatePokemon(c *gin.Context) {
 // @Failure      404 {object}  utils.BaseResponse  "Pokemons not found"
 // @Router       /pokemon/delete [delete]
 func DeletePokemon(c *gin.Context) {
-	id := c.Query("id")
-	var pokemon models.Pokemon
-
-	if err := config.DB.First(&pokemon, id).Error; err != nil {
+	id, ok := utils.ResourceID(c)
+	pokemon, err := findFavorite(utils.DB(c), id)
+	if !ok || err != nil {
 		utils.Response(c, http.StatusNotFound, false, "Pokemon not found", nil)
 		return
 	}
 
-	config.DB.Delete(&pokemon)
-	utils.Response(c, http.StatusOK, true, "Pokemon deleted", pokemon)
+	// check the If-Match precondition
+	if !utils.CheckIfMatch(c, utils.ETag(pokemon.Version)) {
+		return
+	}
+
+	if err := deleteFavorite(utils.DB(c), pokemon); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+	utils.Deleted(c, "Pokemon deleted", pokemon)
+}
+
+// applyVariant validates the variant against the species catalog and picks
+// the matching sprite when no custom sprite is set
+func applyVariant(pokemon *models.Pokemon) error {
+	s, err := species.Validate(config.DB, pokemon.Name, pokemon.Form, pokemon.Gender)
+	if err != nil {
+		return err
+	}
+	if s != nil && (pokemon.Sprite == "" || species.IsCatalogSprite(pokemon.Sprite)) {
+		pokemon.Sprite = species.SpriteURL(s, pokemon.Shiny, pokemon.Form, pokemon.Gender)
+	}
+	return nil
+}
+
+// pokemonFilterFields are the columns GetPokemons and the export filter on
+var pokemonFilterFields = map[string]string{
+	"user_email": "string",
+	"name":       "string",
+	"type":       "string",
+	"notes":      "string",
+	"shiny":      "bool",
+	"form":       "exact",
+	"gender":     "exact",
+	"id":         "int",
+	"created_at": "date",
+	"updated_at": "date",
+}
+
+// filterPokemons applies the field, tag and box filters of the query
+func filterPokemons(db *gorm.DB, query url.Values) (*gorm.DB, error) {
+	filter, err := utils.ParseFilterValues(query, pokemonFilterFields)
+	if err != nil {
+		return db, err
+	}
+	db = filter.Apply(db)
+
+	// handle tag and box filter, the subqueries run on the connection of
+	// db so they stay in its transaction
+	sub := db.Session(&gorm.Session{NewDB: true})
+	for _, tag := range strings.Split(query.Get("tag"), ",") {
+		if tag = normalizeTag(tag); tag != "" {
+			db = db.Where("id IN (?)", sub.Table("pokemon_tags").
+				Select("pokemon_tags.pokemon_id").
+				Joins("JOIN tags ON tags.id = pokemon_tags.tag_id").
+				Where("tags.name = ?", tag))
+		}
+	}
+	if boxID := query.Get("box_id"); boxID != "" {
+		db = db.Where("id IN (?)", sub.Table("box_pokemons").
+			Select("pokemon_id").
+			Where("box_id = ?", boxID))
+	}
+	return db, nil
+}
+
+// pokemonFields maps the fields= names of a pokemon to their columns
+var pokemonFields = map[string]string{
+	"id":        "id",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	"userEmail": "user_email",
+	"name":      "name",
+	"type":      "type",
+	"notes":     "notes",
+	"sprite":    "sprite",
+	"shiny":     "shiny",
+	"form":      "form",
+	"gender":    "gender",
+	"version":   "version",
+}
+
+// pokemonIncludes are the resources include= can embed, with the column
+// each one is loaded by
+var pokemonIncludes = map[string]string{
+	"owner":   "user_email",
+	"species": "name",
+	"tags":    "id",
+}
+
+// parsePokemonFieldset reads fields= and include= for pokemons
+func parsePokemonFieldset(c *gin.Context) (*utils.Fieldset, map[string]bool, error) {
+	fieldset, err := utils.ParseFieldset(c, pokemonFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	includes, err := utils.ParseIncludes(c, "owner", "species", "tags")
+	if err != nil {
+		return nil, nil, err
+	}
+	return fieldset, includes, nil
+}
+
+// selectPokemons narrows the query to the fieldset while keeping the columns
+// the includes and the cursors need, tags are preloaded in one query
+func selectPokemons(db *gorm.DB, fieldset *utils.Fieldset, includes map[string]bool, required ...string) *gorm.DB {
+	required = append(required, "id")
+	for include := range includes {
+		required = append(required, pokemonIncludes[include])
+	}
+	db = fieldset.Select(db, required...)
+	if fieldset == nil || includes["tags"] {
+		db = db.Preload("Tags")
+	}
+	return db
+}
+
+// embedPokemons projects the pokemons on the fieldset and embeds the
+// included resources, each relation is loaded with a single query. The
+// owner is only embedded for the owner and the admins.
+func embedPokemons(c *gin.Context, pokemons []models.Pokemon, fieldset *utils.Fieldset, includes map[string]bool) ([]map[string]interface{}, error) {
+	db := utils.DB(c)
+	owners := map[string]dto.OwnerResponse{}
+	if includes["owner"] {
+		var emails []string
+		for _, p := range pokemons {
+			if canEditFavorite(c.GetString("email"), c.GetString("role"), p) {
+				emails = append(emails, p.UserEmail)
+			}
+		}
+		var users []models.User
+		if err := db.Select("id", "name", "email").Where("email IN ?", emails).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			owners[u.Email] = dto.OwnerResponse{ID: u.ID, Name: u.Name, Email: u.Email}
+		}
+	}
+
+	catalog := map[string]models.Species{}
+	if includes["species"] {
+		var names []string
+		for _, p := range pokemons {
+			names = append(names, strings.ToLower(strings.TrimSpace(p.Name)))
+		}
+		var rows []models.Species
+		if err := db.Where("name IN ?", names).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, s := range rows {
+			catalog[s.Name] = s
+		}
+	}
+
+	var extra []string
+	if includes["tags"] {
+		extra = append(extra, "tags")
+	}
+	items := make([]map[string]interface{}, 0, len(pokemons))
+	for _, p := range pokemons {
+		item, err := fieldset.Project(p, extra...)
+		if err != nil {
+			return nil, err
+		}
+		if includes["owner"] {
+			if owner, ok := owners[p.UserEmail]; ok {
+				item["owner"] = owner
+			} else {
+				item["owner"] = nil
+			}
+		}
+		if includes["species"] {
+			if s, ok := catalog[strings.ToLower(strings.TrimSpace(p.Name))]; ok {
+				item["species"] = s
+			} else {
+				item["species"] = nil
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
 }
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"go-api/internal/recommend"
+	"go-api/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Get my recommendations
+// GetMyRecommendations godoc
+// @Summary      Get my recommendations
+// @Description  Recommend species the logged in user has not favorited: first the species other trainers favorited along theirs, then the most favorited species of their types (or of the given types), then the most favorited species
+// @Tags         Recommendations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        type   query     string  false  "Types to fall back on, comma separated, e.g. fire,water"
+// @Param        limit  query     int     false  "Number of recommendations (max 50)"
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to fetch recommendations"
+// @Router 		 /me/recommendations [get]
+func GetMyRecommendations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	recommendations, err := recommend.Recommend(c.Request.Context(), utils.DB(c), c.GetString("email"), searchList(c.Query("type")), limit)
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to fetch recommendations", nil)
+		return
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching recommendations", recommendations)
+}
+
+// Refresh recommendations
+// RefreshRecommendations godoc
+// @Summary      Refresh recommendations
+// @Description  Compute the species neighbors from the favorites now instead of waiting for the background job
+// @Tags         Recommendations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to refresh recommendations"
+// @Router 		 /recommendations/refresh [post]
+func RefreshRecommendations(c *gin.Context) {
+	neighbors, err := recommend.Compute(c.Request.Context(), utils.DB(c), recommend.LoadSettings())
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to refresh recommendations", nil)
+		return
+	}
+
+	utils.Response(c, http.StatusOK, true, "Recommendations refreshed", gin.H{"neighbors": neighbors})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"go-api/internal/dto"
+	"go-api/internal/middleware"
+	"go-api/internal/search"
+	"go-api/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Search routes
+// Search godoc
+// @Summary      Search favorites and species
+// @Description  Rank the favorites and species matching every word of q, with typo tolerance ("charzard" finds Charizard). Matches are wrapped in <mark> in the highlights, which are escaped HTML, the facets count the hits by type before the type filter. Users search their own favorites, admins everyone's
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q      query     string  true   "Text to search"
+// @Param        kind   query     string  false  "Kinds to search, comma separated: favorite, species"
+// @Param        type   query     string  false  "Only hits having one of these types, comma separated"
+// @Param        page   query     int     false  "Page number for pagination"
+// @Param        limit  query     int     false  "Number of items per page (max 100)"
+// @Success      200    {object}  utils.BaseResponse{data=dto.SearchResponse}
+// @Failure      400    {object}  utils.BaseResponse  "Missing text or invalid kind"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to search"
+// @Router 		 /search [get]
+func Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		utils.Response(c, http.StatusBadRequest, false, "Query parameter q is required", nil)
+		return
+	}
+
+	kinds := searchList(c.Query("kind"))
+	for _, kind := range kinds {
+		if kind != search.Favorite && kind != search.Species {
+			utils.Response(c, http.StatusBadRequest, false, "Invalid kind: "+kind, nil)
+			return
+		}
+	}
+
+	// get pagination
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	// users only search their own favorites
+	query := search.Query{
+		Text:   text,
+		Kinds:  kinds,
+		Types:  searchList(c.Query("type")),
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	if !middleware.HasRole(c.GetString("role"), "admin") {
+		query.Owner = c.GetString("email")
+	}
+
+	if search.Default == nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to search", nil)
+		return
+	}
+	result, err := search.Default.Search(c.Request.Context(), query)
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to search", nil)
+		return
+	}
+
+	// build the reponse
+	totalPages := int((result.Total + int64(limit) - 1) / int64(limit))
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	dataResponse := dto.SearchResponse{
+		DataResponse: utils.DataResponse{
+			CurrentPage:     page,
+			TotalPages:      totalPages,
+			TotalItems:      result.Total,
+			Limit:           limit,
+			HasNextPage:     page < totalPages,
+			HasPreviousPage: page > 1,
+			Items:           result.Hits,
+		},
+		Facets: result.Facets,
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching search results", dataResponse)
+}
+
+// searchList reads a comma separated parameter in lower case
+func searchList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
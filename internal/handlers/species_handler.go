@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"go-api/internal/dto"
+	"go-api/internal/models"
+	"go-api/internal/utils"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Get all species
+
+// Species routes
+// GetSpecies godoc
+// @Summary      Get all species
+// @Description  Get the species catalog with the forms each species allows. Fields also accept operators: field[eq|ne|like|lt|lte|gt|gte|in|nin|null]=value, and or[group][field][op]=value OR groups
+// @Tags         Species
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name   query     string  false  "Filter by name"
+// @Param        types  query     string  false  "Filter by types"
+// @Param        page   query     int     false  "Page number for pagination"
+// @Param        limit  query     int     false  "Number of items per page"
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      400    {object}  utils.BaseResponse  "Invalid filter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      404    {object}  utils.BaseResponse  "Species not found"
+// @Router 		 /species [get]
+func GetSpecies(c *gin.Context) {
+	var species []models.Species
+	db := utils.DB(c)
+
+	// hanlde filter
+	allowedField := map[string]string{
+		"name":  "string",
+		"types": "string",
+		"id":         "int",
+		"gigantamax": "bool",
+	}
+	db, err := utils.ApplyFilters(c, db, allowedField)
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	// get pagination
+	db, pagination := utils.ApplyPagination(c, db, &models.Species{}, speciesSortFields)
+	if pagination.Err != nil {
+		utils.Response(c, http.StatusBadRequest, false, pagination.Err.Error(), nil)
+		return
+	}
+
+	if err := db.Find(&species).Error; err != nil || len(species) == 0 {
+		utils.Response(c, http.StatusNotFound, false, "Species not found", nil)
+		return
+	}
+	utils.FinalizePagination(&pagination, &species)
+
+	// build the reponse
+	dataResponse := utils.DataResponse{
+		CurrentPage:     pagination.Page,
+		TotalPages:      pagination.TotalPages,
+		TotalItems:      pagination.TotalItems,
+		Limit:           pagination.Limit,
+		HasNextPage:     pagination.HasNextPage,
+		HasPreviousPage: pagination.HasPreviousPage,
+		NextCursor:      pagination.NextCursor,
+		PrevCursor:      pagination.PrevCursor,
+		Items:           species,
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching species data", dataResponse)
+}
+
+// speciesSortFields are the columns GetSpecies sorts on
+var speciesSortFields = map[string]bool{"id": true, "name": true, "types": true}
+
+// Get my completion
+// GetMyCompletion godoc
+// @Summary      Get my completion stats
+// @Description  Count the species and variants collected by the logged in user
+// @Tags         Species
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        shiny   query     bool    false  "Only count shiny favorites"
+// @Param        form    query     string  false  "Only count favorites of this form"
+// @Param        gender  query     string  false  "Only count favorites of this gender"
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      400    {object}  utils.BaseResponse  "Invalid filter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to compute completion"
+// @Router 		 /me/completion [get]
+func GetMyCompletion(c *gin.Context) {
+	// favorites of the user narrowed by the variant filters
+	filter, err := utils.ParseFilters(c, map[string]string{
+		"shiny":  "bool",
+		"form":   "exact",
+		"gender": "exact",
+	})
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+	favorites := func() *gorm.DB {
+		db := utils.DB(c).Model(&models.Pokemon{}).Where("user_email = ?", c.GetString("email"))
+		return filter.Apply(db)
+	}
+
+	dataResponse := dto.CompletionResponse{
+		Forms:   map[string]int64{},
+		Genders: map[string]int64{},
+	}
+	if err := utils.DB(c).Model(&models.Species{}).Count(&dataResponse.TotalSpecies).Error; err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to compute completion", nil)
+		return
+	}
+	favorites().Count(&dataResponse.Favorites)
+	favorites().Where("shiny = ?", true).Count(&dataResponse.Shiny)
+	favorites().
+		Where("LOWER(name) IN (?)", utils.DB(c).Model(&models.Species{}).Select("name")).
+		Distinct("LOWER(name)").
+		Count(&dataResponse.OwnedSpecies)
+
+	// count by form and gender
+	var groups []struct {
+		Value string
+		Count int64
+	}
+	favorites().Select("form AS value, COUNT(*) AS count").Where("form <> ''").Group("form").Scan(&groups)
+	for _, g := range groups {
+		dataResponse.Forms[g.Value] = g.Count
+	}
+	groups = nil
+	favorites().Select("gender AS value, COUNT(*) AS count").Where("gender <> ''").Group("gender").Scan(&groups)
+	for _, g := range groups {
+		dataResponse.Genders[g.Value] = g.Count
+	}
+
+	if dataResponse.TotalSpecies > 0 {
+		ratio := float64(dataResponse.OwnedSpecies) / float64(dataResponse.TotalSpecies) * 100
+		dataResponse.Completion = math.Round(ratio*100) / 100
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching completion data", dataResponse)
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-api/config"
+	"go-api/internal/events"
+	"go-api/internal/imaging"
+	"go-api/internal/models"
+	"go-api/internal/outbox"
+	"go-api/internal/spriteproxy"
+	"go-api/internal/storage"
+	"go-api/internal/utils"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// signedURLTTL is how long a signed file URL stays valid
+const signedURLTTL = 15 * time.Minute
+
+// Upload pokemon sprite
+
+// Sprite routes
+// UploadPokemonSprite godoc
+// @Summary      Upload pokemon sprite
+// @Description  Upload a custom PNG, GIF or WebP sprite, thumbnails are generated automatically
+// @Tags         Sprites
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    query     integer  true  "id"
+// @Param        file  formData  file     true  "Sprite image"
+// @Success      201 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Invalid image"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Pokemon not found"
+// @Failure      409 {object} utils.BaseResponse "Pokemon changed during the upload"
+// @Failure      413 {object} utils.BaseResponse "Image too large"
+// @Failure      415 {object} utils.BaseResponse "Unsupported image type"
+// @Router       /pokemon/sprite [post]
+func UploadPokemonSprite(c *gin.Context) {
+	id, ok := utils.ResourceID(c)
+	var pokemon models.Pokemon
+	if err := utils.DB(c).First(&pokemon, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Pokemon not found", nil)
+		return
+	}
+	if !canEditFavorite(c.GetString("email"), c.GetString("role"), pokemon) {
+		utils.Response(c, http.StatusForbidden, false, "Forbidden access", nil)
+		return
+	}
+
+	// read the file within the size limit, the request is cut before the
+	// form is spooled, leaving room for the multipart headers
+	maxBytes := spriteMaxBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+	file, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		utils.Response(c, http.StatusRequestEntityTooLarge, false, fmt.Sprintf("Image must be at most %d bytes", maxBytes), nil)
+		return
+	}
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, "file is required", nil)
+		return
+	}
+	if file.Size > maxBytes {
+		utils.Response(c, http.StatusRequestEntityTooLarge, false, fmt.Sprintf("Image must be at most %d bytes", maxBytes), nil)
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, "Failed to read file", nil)
+		return
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, maxBytes+1))
+	if err != nil || int64(len(data)) > maxBytes {
+		utils.Response(c, http.StatusRequestEntityTooLarge, false, fmt.Sprintf("Image must be at most %d bytes", maxBytes), nil)
+		return
+	}
+
+	// validate the content by sniffing
+	info, err := imaging.Inspect(data)
+	if errors.Is(err, imaging.ErrUnsupportedType) {
+		utils.Response(c, http.StatusUnsupportedMediaType, false, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	// store the original and its thumbnails
+	key := fmt.Sprintf("sprites/%d/%s%s", pokemon.ID, randomName(), info.Extension)
+	ctx := c.Request.Context()
+	if err := storage.Blobs.Put(ctx, key, data, info.ContentType); err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to store sprite", nil)
+		return
+	}
+	for _, size := range imaging.ThumbnailSizes {
+		thumb, err := imaging.Thumbnail(data, size)
+		if err == nil {
+			err = storage.Blobs.Put(ctx, thumbnailKey(key, size), thumb, "image/png")
+		}
+		if err != nil {
+			deleteSpriteBlobs(c, key)
+			utils.Response(c, http.StatusInternalServerError, false, "Failed to generate thumbnails", nil)
+			return
+		}
+	}
+
+	upload := models.SpriteUpload{
+		UserEmail:   c.GetString("email"),
+		PokemonID:   pokemon.ID,
+		Key:         key,
+		ContentType: info.ContentType,
+		Size:        int64(len(data)),
+		Width:       info.Width,
+		Height:      info.Height,
+	}
+	pokemon.Sprite = fmt.Sprintf("/api/v1/pokemon/sprite?id=%d", pokemon.ID)
+	err = outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := tx.Create(&upload).Error; err != nil {
+			return err
+		}
+		if err := utils.UpdateVersioned(tx, &pokemon, &pokemon.Version, "sprite"); err != nil {
+			return err
+		}
+		return recordFavoriteEvent(tx, events.PokemonUpdated, pokemon)
+	})
+	if err != nil {
+		deleteSpriteBlobs(c, key)
+		if errors.Is(err, utils.ErrVersionConflict) {
+			utils.Response(c, http.StatusConflict, false, "Pokemon changed during the upload, try again", nil)
+			return
+		}
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to save sprite", nil)
+		return
+	}
+
+	// replace the previous upload
+	var previous []models.SpriteUpload
+	utils.DB(c).Where("pokemon_id = ? AND id <> ?", pokemon.ID, upload.ID).Find(&previous)
+	for _, p := range previous {
+		deleteSpriteBlobs(c, p.Key)
+		utils.DB(c).Delete(&p)
+	}
+
+	c.Header("ETag", utils.ETag(pokemon.Version))
+	utils.Response(c, http.StatusCreated, true, "Sprite uploaded", spriteURLs(upload))
+}
+
+// Get pokemon sprite
+// GetPokemonSprite godoc
+// @Summary      Get pokemon sprite
+// @Description  Redirect to a signed URL of the uploaded sprite or one of its thumbnails
+// @Tags         Sprites
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    query     integer  true   "id"
+// @Param        size  query     integer  false  "Thumbnail size (64, 128 or 256)"
+// @Success      302
+// @Failure      400 {object} utils.BaseResponse "Invalid size"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      404 {object} utils.BaseResponse "Sprite not found"
+// @Router       /pokemon/sprite [get]
+func GetPokemonSprite(c *gin.Context) {
+	id, ok := utils.ResourceID(c)
+	var upload models.SpriteUpload
+	if err := utils.DB(c).Where("pokemon_id = ?", id).Last(&upload).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Sprite not found", nil)
+		return
+	}
+
+	key := upload.Key
+	if sizeParam := c.Query("size"); sizeParam != "" {
+		size, err := strconv.Atoi(sizeParam)
+		if err != nil || !slices.Contains(imaging.ThumbnailSizes, size) {
+			utils.Response(c, http.StatusBadRequest, false, "Invalid size", imaging.ThumbnailSizes)
+			return
+		}
+		key = thumbnailKey(key, size)
+	}
+
+	c.Redirect(http.StatusFound, storage.SignURL(key, signedURLTTL))
+}
+
+// Serve file
+// ServeFile godoc
+// @Summary      Serve stored file
+// @Description  Serve a stored file through a signed URL
+// @Tags         Sprites
+// @Produce      image/png
+// @Param        key        path      string  true  "File key"
+// @Param        expires    query     string  true  "Expiry unix timestamp"
+// @Param        signature  query     string  true  "URL signature"
+// @Success      200
+// @Failure      403 {object} utils.BaseResponse "Invalid or expired signature"
+// @Failure      404 {object} utils.BaseResponse "File not found"
+// @Router       /files/{key} [get]
+func ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	expires := c.Query("expires")
+	if !storage.VerifySignature(key, expires, c.Query("signature")) {
+		utils.Response(c, http.StatusForbidden, false, "Invalid or expired signature", nil)
+		return
+	}
+
+	body, contentType, err := storage.Blobs.Get(c.Request.Context(), key)
+	if err != nil {
+		utils.Response(c, http.StatusNotFound, false, "File not found", nil)
+		return
+	}
+	defer body.Close()
+
+	unix, _ := strconv.ParseInt(expires, 10, 64)
+	maxAge := max(0, unix-time.Now().Unix())
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	c.Header("X-Content-Type-Options", "nosniff")
+	if strings.HasPrefix(key, "exports/") {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(key)))
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, body, nil)
+}
+
+// spriteURLs returns signed URLs of an upload and its thumbnails
+func spriteURLs(upload models.SpriteUpload) gin.H {
+	thumbnails := gin.H{}
+	for _, size := range imaging.ThumbnailSizes {
+		thumbnails[strconv.Itoa(size)] = storage.SignURL(thumbnailKey(upload.Key, size), signedURLTTL)
+	}
+	return gin.H{
+		"upload":     upload,
+		"url":        storage.SignURL(upload.Key, signedURLTTL),
+		"thumbnails": thumbnails,
+		"expiresIn":  int(signedURLTTL.Seconds()),
+	}
+}
+
+func deleteSpriteBlobs(c *gin.Context, key string) {
+	storage.Blobs.Delete(c.Request.Context(), key)
+	for _, size := range imaging.ThumbnailSizes {
+		storage.Blobs.Delete(c.Request.Context(), thumbnailKey(key, size))
+	}
+}
+
+func thumbnailKey(key string, size int) string {
+	return fmt.Sprintf("%s_%d.png", key[:strings.LastIndex(key, ".")], size)
+}
+
+// multipartOverhead bounds the multipart headers and boundaries around an
+// uploaded file
+const multipartOverhead = 64 << 10
+
+func spriteMaxBytes() int64 {
+	maxBytes, err := strconv.ParseInt(config.GetEnv("SPRITE_MAX_BYTES"), 10, 64)
+	if err != nil || maxBytes <= 0 {
+		maxBytes = 2 << 20 // Default 2 MiB
+	}
+	return maxBytes
+}
+
+func randomName() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Proxy sprite
+// ProxySprite godoc
+// @Summary      Proxy external sprite
+// @Description  Fetch an allow-listed external sprite through the server cache
+// @Tags         Sprites
+// @Produce      image/png
+// @Param        url            query     string  true   "Upstream sprite URL"
+// @Param        If-None-Match  header    string  false  "ETag of the cached copy"
+// @Success      200
+// @Success      304
+// @Failure      400 {object} utils.BaseResponse "Invalid sprite url"
+// @Failure      403 {object} utils.BaseResponse "Sprite host is not allowed"
+// @Failure      502 {object} utils.BaseResponse "Failed to fetch upstream image"
+// @Router       /sprites/proxy [get]
+func ProxySprite(c *gin.Context) {
+	image, err := spriteproxy.Default.Fetch(c.Request.Context(), c.Query("url"))
+	switch {
+	case errors.Is(err, spriteproxy.ErrInvalidURL):
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	case errors.Is(err, spriteproxy.ErrHostNotAllowed):
+		utils.Response(c, http.StatusForbidden, false, err.Error(), nil)
+		return
+	case err != nil:
+		utils.Response(c, http.StatusBadGateway, false, err.Error(), nil)
+		return
+	}
+
+	maxAge := max(0, int(time.Until(image.Expires).Seconds()))
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	if utils.NotModified(c, image.ETag) {
+		return
+	}
+	c.Data(http.StatusOK, image.ContentType, image.Body)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"go-api/internal/dto"
+	"go-api/internal/events"
+	"go-api/internal/models"
+	"go-api/internal/outbox"
+	"go-api/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Get tags
+
+// Tag routes
+// GetTags godoc
+// @Summary      Autocomplete tags
+// @Description  Get the tags of the logged in user starting with the given prefix
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q      query     string  false  "Tag prefix"
+// @Param        limit  query     int     false  "Maximum number of tags, default is 10"
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to fetch tags"
+// @Router 		 /tags [get]
+func GetTags(c *gin.Context) {
+	email := c.GetString("email")
+	prefix := normalizeTag(c.Query("q"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	var tags []models.Tag
+	if err := utils.DB(c).
+		Where(`user_email = ? AND name LIKE ? ESCAPE '\'`, email, likeEscaper.Replace(prefix)+"%").
+		Order("name asc").
+		Limit(limit).
+		Find(&tags).Error; err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to fetch tags", nil)
+		return
+	}
+
+	utils.Response(c, http.StatusOK, true, "Succes fetching tags data", tags)
+}
+
+// Set pokemon tags
+// SetPokemonTags godoc
+// @Summary      Set pokemon tags
+// @Description  Replace the tags of a pokemon, unknown tags are created for the owner. Only the owner and the admins can
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Param        credentials body dto.SetPokemonTagsRequest true "Pokemon tags"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Validation error"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Pokemon not found"
+// @Failure      412 {object} utils.BaseResponse "Pokemon changed since it was read"
+// @Router       /pokemon/tags [put]
+func SetPokemonTags(c *gin.Context) {
+	id, ok := utils.ResourceID(c)
+
+	// get the serializer and validate it
+	var req dto.SetPokemonTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	// fetching pokemon data
+	var pokemon models.Pokemon
+	if err := utils.DB(c).First(&pokemon, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Pokemon not found", nil)
+		return
+	}
+	if !canEditFavorite(c.GetString("email"), c.GetString("role"), pokemon) {
+		utils.Response(c, http.StatusForbidden, false, "Forbidden access", nil)
+		return
+	}
+
+	// check the If-Match precondition
+	if !utils.CheckIfMatch(c, utils.ETag(pokemon.Version)) {
+		return
+	}
+
+	// replace the tags and bump the version, the ETag covers the tags
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		tags, err := findOrCreateTags(tx, pokemon.UserEmail, req.Tags)
+		if err != nil {
+			return err
+		}
+		if err := utils.UpdateVersioned(tx, &pokemon, &pokemon.Version, "version"); err != nil {
+			return err
+		}
+		if err := tx.Model(&pokemon).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		return recordFavoriteEvent(tx, events.PokemonUpdated, pokemon)
+	})
+	if errors.Is(err, utils.ErrVersionConflict) {
+		utils.Response(c, http.StatusPreconditionFailed, false, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to update tags", nil)
+		return
+	}
+
+	c.Header("ETag", utils.ETag(pokemon.Version))
+	utils.Response(c, http.StatusOK, true, "Pokemon tags updated", pokemon)
+}
+
+// findOrCreateTags resolves tag names of a user, creating the missing ones
+func findOrCreateTags(db *gorm.DB, email string, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = normalizeTag(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		tag := models.Tag{UserEmail: email, Name: name}
+		if err := db.Where(tag).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
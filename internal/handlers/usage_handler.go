@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"go-api/internal/dto"
+	"go-api/internal/usage"
+	"go-api/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUsageWindow caps the window of the usage reports
+const maxUsageWindow = 90 * 24 * time.Hour
+
+// Usage routes
+// GetEndpointUsage godoc
+// @Summary      Get the usage of the endpoints
+// @Description  Report the requests, error rates and p50/p95/p99 latencies of each endpoint over a window. Endpoints are route templates such as /api/v2/pokemons/:id, and the window covers whole hours
+// @Tags         Usage
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        window  query     string  false  "Duration such as 1h or 168h (default 24h, max 2160h)"
+// @Param        sort    query     string  false  "requests, error_rate or p95 (default requests)"
+// @Param        limit   query     int     false  "Number of endpoints (default 50, max 500)"
+// @Success      200    {object}  utils.BaseResponse{data=dto.UsageResponse}
+// @Failure      400    {object}  utils.BaseResponse  "Invalid parameter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to compute usage"
+// @Router 		 /usage/endpoints [get]
+func GetEndpointUsage(c *gin.Context) {
+	window, ok := usageWindow(c)
+	if !ok {
+		return
+	}
+	since := time.Now().Add(-window)
+
+	endpoints, err := usage.Endpoints(c.Request.Context(), utils.DB(c), since, c.DefaultQuery("sort", usage.SortRequests), usageLimit(c, 50, 500))
+	if errors.Is(err, usage.ErrInvalidSort) {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to compute usage", nil)
+		return
+	}
+
+	// build the reponse
+	dataResponse := dto.UsageResponse{Since: usage.Hour(since), Window: window.String(), Items: endpoints}
+	utils.Response(c, http.StatusOK, true, "Succes fetching usage", dataResponse)
+}
+
+// GetTopClients godoc
+// @Summary      Get the top clients
+// @Description  Rank the clients by requests over a window, by IP or by user. The window covers whole hours
+// @Tags         Usage
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        by      query     string  false  "ip or user (default ip)"
+// @Param        window  query     string  false  "Duration such as 1h or 168h (default 24h, max 2160h)"
+// @Param        limit   query     int     false  "Number of clients (default 20, max 500)"
+// @Success      200    {object}  utils.BaseResponse{data=dto.UsageResponse}
+// @Failure      400    {object}  utils.BaseResponse  "Invalid parameter"
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to compute usage"
+// @Router 		 /usage/clients [get]
+func GetTopClients(c *gin.Context) {
+	window, ok := usageWindow(c)
+	if !ok {
+		return
+	}
+	since := time.Now().Add(-window)
+
+	clients, err := usage.TopClients(c.Request.Context(), utils.DB(c), c.DefaultQuery("by", usage.ByIP), since, usageLimit(c, 20, 500))
+	if errors.Is(err, usage.ErrInvalidClientKind) {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to compute usage", nil)
+		return
+	}
+
+	// build the reponse
+	dataResponse := dto.UsageResponse{Since: usage.Hour(since), Window: window.String(), Items: clients}
+	utils.Response(c, http.StatusOK, true, "Succes fetching usage", dataResponse)
+}
+
+// RefreshUsage godoc
+// @Summary      Refresh usage
+// @Description  Roll up the requests logged since the last refresh now instead of waiting for the background job
+// @Tags         Usage
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200    {object}  utils.BaseResponse
+// @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      500    {object}  utils.BaseResponse  "Failed to refresh usage"
+// @Router 		 /usage/refresh [post]
+func RefreshUsage(c *gin.Context) {
+	rollup, err := usage.Refresh(c.Request.Context(), utils.DB(c), usage.LoadSettings())
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to refresh usage", nil)
+		return
+	}
+
+	utils.Response(c, http.StatusOK, true, "Usage refreshed", rollup)
+}
+
+// usageWindow reads the window of a report, by default a day
+func usageWindow(c *gin.Context) (time.Duration, bool) {
+	window, err := time.ParseDuration(c.DefaultQuery("window", "24h"))
+	if err != nil || window <= 0 || window > maxUsageWindow {
+		utils.Response(c, http.StatusBadRequest, false, "window must be a duration between 1s and 2160h", nil)
+		return 0, false
+	}
+	return window, true
+}
+
+func usageLimit(c *gin.Context, fallback, max int) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return fallback
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
-	"go-api/config"
+	"errors"
 	"go-api/internal/dto"
+	"go-api/internal/events"
 	"go-api/internal/models"
+	"go-api/internal/outbox"
 	"go-api/internal/utils"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Get all users
@@ -15,7 +19,7 @@ import (
 // User routes
 // GetUsers godoc
 // @Summary      Get all users
-// @Description  Get list of users with filters
+// @Description  Get list of users with filters. Fields also accept operators: field[eq|ne|like|lt|lte|gt|gte|in|nin|null]=value, and or[group][field][op]=value OR groups
 // @Tags         Users
 // @Accept       json
 // @Produce      json
@@ -25,7 +29,12 @@ import (
 // @Param        role   query     string  false  "Role filter"
 // @Param        page     query     int     false  "Page number for pagination"
 // @Param        limit    query     int     false  "Number of items per page"
+// @Param        paginate query     string  false  "Set to cursor for keyset pagination"
+// @Param        cursor   query     string  false  "Cursor from nextCursor or prevCursor"
+// @Param        include_total query bool   false  "Count the total items (default true for offset, false for cursor)"
+// @Param        fields   query     string  false  "Comma separated fields to return, e.g. id,name,email"
 // @Success      200    {object}  utils.BaseResponse
+// @Failure      400    {object}  utils.BaseResponse  "Invalid filter, cursor or field"
 // @Failure      401    {object}  utils.BaseResponse  "Missing or invalid token"
 // @Failure      403    {object}  utils.BaseResponse  "Forbidden access"
 // @Failure      404    {object}  utils.BaseResponse  "Users not found"
@@ -35,26 +44,54 @@ func GetUsers(c *gin.Context) {
 	var users []models.User
 
 	// fetching data from database
-	db := config.DB
+	db := utils.DB(c)
 
 	// hanlde filter
-	allowedField := map[string]string{
-		"name":  "string",
-		"email": "string",
-		"role":  "string",
+	db, err := utils.ApplyFilters(c, db, userFilterFields)
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	// get sparse fields
+	fieldset, err := utils.ParseFieldset(c, userFields)
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
 	}
-	db = utils.ApplyFilters(c, db, allowedField)
 
 	// get pagination
-	db, pagination := utils.ApplyPagination(c, db, &models.User{})
+	db, pagination := utils.ApplyPagination(c, db, &models.User{}, userSortFields)
+	if pagination.Err != nil {
+		utils.Response(c, http.StatusBadRequest, false, pagination.Err.Error(), nil)
+		return
+	}
 
 	// fetch user data
+	db = fieldset.Select(db, append(pagination.Columns(), "id")...)
 	if err := db.Find(&users).Error; err != nil {
 		utils.Response(c, http.StatusNotFound, false, "Failed to fetch users", nil)
 		return
 	}
 
-	if len(users) == 0 {
+	utils.FinalizePagination(&pagination, &users)
+
+	// project the sparse fields
+	var items interface{} = users
+	if fieldset != nil {
+		projected := make([]map[string]interface{}, 0, len(users))
+		for _, user := range users {
+			item, err := fieldset.Project(user)
+			if err != nil {
+				utils.Response(c, http.StatusInternalServerError, false, "Failed to fetch users", nil)
+				return
+			}
+			projected = append(projected, item)
+		}
+		items = projected
+	}
+
+	if len(users) == 0 && utils.APIVersion(c) == 1 {
 		utils.Response(c, http.StatusNotFound, false, "Users not found", nil)
 		return
 	}
@@ -67,11 +104,34 @@ func GetUsers(c *gin.Context) {
 		Limit:           pagination.Limit,
 		HasNextPage:     pagination.HasNextPage,
 		HasPreviousPage: pagination.HasPreviousPage,
-		Items:           users,
+		NextCursor:      pagination.NextCursor,
+		PrevCursor:      pagination.PrevCursor,
+		Items:           items,
 	}
 	utils.Response(c, http.StatusOK, true, "Succes fetching users data", dataResponse)
 }
 
+// userFilterFields are the columns GetUsers and the export filter on
+var userFilterFields = map[string]string{
+	"name":       "string",
+	"email":      "string",
+	"role":       "string",
+	"id":         "int",
+	"created_at": "date",
+	"updated_at": "date",
+}
+
+// userFields maps the fields= names of a user to their columns
+var userFields = map[string]string{
+	"id":        "id",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+	"name":      "name",
+	"email":     "email",
+	"role":      "role",
+	"version":   "version",
+}
+
 // Get User by ID
 // GetUser godoc
 // @Summary      Get user by id
@@ -87,15 +147,20 @@ func GetUsers(c *gin.Context) {
 // @Failure      404    {object}  utils.BaseResponse  "User not found"
 // @Router 		 /user [get]
 func GetUserByID(c *gin.Context) {
-	id := c.Query("id")
+	id, ok := utils.ResourceID(c)
 	var user models.User
 
 	// error handling
-	if err := config.DB.First(&user, id).Error; err != nil {
+	if err := utils.DB(c).First(&user, id).Error; !ok || err != nil {
 		utils.Response(c, http.StatusNotFound, false, "User not found", nil)
 		return
 	}
 
+	// answer 304 when the client copy is current
+	if utils.NotModified(c, utils.ETag(user.Version)) {
+		return
+	}
+
 	// build the reponse
 	dataResponse := utils.DataResponse{
 		CurrentPage:     1,
@@ -118,6 +183,7 @@ func GetUserByID(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        credentials body dto.CreateUserRequest true "Create user credentials"
+// @Param        Idempotency-Key  header  string  false  "Replay the stored response when the request is retried"
 // @Success      200 {object} dto.Token
 // @Failure      400 {object} utils.BaseResponse "Validation error"
 // @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
@@ -147,11 +213,19 @@ func CreateUser(c *gin.Context) {
 	}
 
 	// save the user
-	if err := config.DB.Create(&user).Error; err != nil {
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return outbox.Record(tx, outbox.User, user.ID, events.Event{Type: events.UserCreated, UserEmail: user.Email, Payload: user})
+	})
+	if err != nil {
 		utils.Response(c, http.StatusConflict, false, "User email already used", nil)
 		return
 	}
 
+	c.Header("ETag", utils.ETag(user.Version))
+	c.Header("Location", fmt.Sprintf("/api/v2/users/%d", user.ID))
 	utils.Response(c, http.StatusCreated, true, "User created", user)
 }
 
@@ -171,7 +245,7 @@ func CreateUser(c *gin.Context) {
 // @Failure      404 {object}  utils.BaseResponse  "Users not found"
 // @Router       /user/update [put]
 func UpdateUser(c *gin.Context) {
-	id := c.Query("id")
+	id, ok := utils.ResourceID(c)
 
 	// get the serializer and validate it
 	var req dto.UpdateUserRequest
@@ -182,21 +256,131 @@ func UpdateUser(c *gin.Context) {
 
 	// fetching user data
 	var user = models.User{}
-	if err := config.DB.First(&user, id).Error; err != nil {
+	if err := utils.DB(c).First(&user, id).Error; !ok || err != nil {
 		utils.Response(c, http.StatusNotFound, false, "User not found", nil)
 		return
 	}
 
-	// update the user data
-	user.Name = req.Name
-	user.Email = req.Email
-	user.Role = req.Role
+	// check the If-Match precondition
+	if !utils.CheckIfMatch(c, utils.ETag(user.Version)) {
+		return
+	}
+
+	// update the user data, omitted fields are kept
+	previousRole := user.Role
+	if req.Name != "" {
+		user.Name = req.Name
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	if req.Role != "" {
+		user.Role = req.Role
+	}
 
 	// save the user
-	config.DB.Save(&user)
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := utils.UpdateVersioned(tx, &user, &user.Version); err != nil {
+			return err
+		}
+		return recordUserUpdate(tx, user, previousRole)
+	})
+	if errors.Is(err, utils.ErrVersionConflict) {
+		utils.VersionConflictResponse(c)
+		return
+	} else if err != nil {
+		utils.Response(c, http.StatusConflict, false, "User email already used", nil)
+		return
+	}
+	c.Header("ETag", utils.ETag(user.Version))
+	utils.Response(c, http.StatusOK, true, "User updated", user)
+}
+
+// Patch User
+// PatchUser godoc
+// @Summary      Patch user
+// @Description  Partially update a user with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902)
+// @Tags         Users
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Param        patch body dto.PatchUserDocument true "Merge patch or JSON patch operations"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object}  utils.BaseResponse  "Invalid patch"
+// @Failure      401 {object}  utils.BaseResponse  "Missing or invalid token"
+// @Failure      403 {object}  utils.BaseResponse  "Forbidden access"
+// @Failure      404 {object}  utils.BaseResponse  "User not found"
+// @Failure      409 {object}  utils.BaseResponse  "Patch test failed or email already used"
+// @Failure      415 {object}  utils.BaseResponse  "Unsupported patch media type"
+// @Router       /user/update [patch]
+func PatchUser(c *gin.Context) {
+	id, ok := utils.ResourceID(c)
+
+	// fetching user data
+	var user models.User
+	if err := utils.DB(c).First(&user, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "User not found", nil)
+		return
+	}
+
+	// check the If-Match precondition
+	if !utils.CheckIfMatch(c, utils.ETag(user.Version)) {
+		return
+	}
+
+	// apply the patch on the current state
+	doc := dto.PatchUserDocument{Name: user.Name, Email: user.Email, Role: user.Role}
+	changed, err := utils.ApplyPatch(c, &doc)
+	if err != nil {
+		utils.PatchErrorResponse(c, err)
+		return
+	}
+	if len(changed) == 0 {
+		utils.Response(c, http.StatusOK, true, "User unchanged", user)
+		return
+	}
+
+	// persist only the changed columns
+	previousRole := user.Role
+	user.Name = doc.Name
+	user.Email = doc.Email
+	user.Role = doc.Role
+	err = outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := utils.UpdateVersioned(tx, &user, &user.Version, changed...); err != nil {
+			return err
+		}
+		return recordUserUpdate(tx, user, previousRole)
+	})
+	if errors.Is(err, utils.ErrVersionConflict) {
+		utils.VersionConflictResponse(c)
+		return
+	} else if err != nil {
+		utils.Response(c, http.StatusConflict, false, "User email already used", nil)
+		return
+	}
+	c.Header("ETag", utils.ETag(user.Version))
+
 	utils.Response(c, http.StatusOK, true, "User updated", user)
 }
 
+// recordUserUpdate writes user.updated, and user.role_changed when the
+// update changed the role, in the transaction of the update
+func recordUserUpdate(tx *gorm.DB, user models.User, previousRole string) error {
+	if err := outbox.Record(tx, outbox.User, user.ID, events.Event{Type: events.UserUpdated, UserEmail: user.Email, Payload: user}); err != nil {
+		return err
+	}
+	if user.Role == previousRole {
+		return nil
+	}
+	return outbox.Record(tx, outbox.User, user.ID, events.Event{
+		Type:      events.UserRoleChanged,
+		UserEmail: user.Email,
+		Payload:   gin.H{"user": user, "previousRole": previousRole},
+	})
+}
+
 // Delete User
 // DeleteUser godoc
 // @Summary      Delete user
@@ -212,14 +396,31 @@ func UpdateUser(c *gin.Context) {
 // @Failure      404 {object}  utils.BaseResponse  "Users not found"
 // @Router       /user/delete [delete]
 func DeleteUser(c *gin.Context) {
-	id := c.Query("id")
+	id, ok := utils.ResourceID(c)
 	var user models.User
 
-	if err := config.DB.First(&user, id).Error; err != nil {
+	if err := utils.DB(c).First(&user, id).Error; !ok || err != nil {
 		utils.Response(c, http.StatusNotFound, false, "User not found", nil)
 		return
 	}
 
-	config.DB.Delete(&user)
-	utils.Response(c, http.StatusOK, true, "User deleted", user)
+	// check the If-Match precondition
+	if !utils.CheckIfMatch(c, utils.ETag(user.Version)) {
+		return
+	}
+
+	err := outbox.Transaction(utils.DB(c), func(tx *gorm.DB) error {
+		if err := utils.DeleteVersioned(tx, &user, user.Version); err != nil {
+			return err
+		}
+		return outbox.Record(tx, outbox.User, user.ID, events.Event{Type: events.UserDeleted, UserEmail: user.Email, Payload: user})
+	})
+	if errors.Is(err, utils.ErrVersionConflict) {
+		utils.VersionConflictResponse(c)
+		return
+	} else if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to delete user", nil)
+		return
+	}
+	utils.Deleted(c, "User deleted", user)
 }
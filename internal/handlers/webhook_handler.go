@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"fmt"
+	"go-api/internal/dto"
+	"go-api/internal/events"
+	"go-api/internal/models"
+	"go-api/internal/utils"
+	"go-api/internal/webhooks"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhook routes
+// GetWebhooks godoc
+// @Summary      Get all webhooks
+// @Description  Get the webhook subscriptions, their secrets are never returned
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} utils.BaseResponse
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Router       /webhooks [get]
+func GetWebhooks(c *gin.Context) {
+	var hooks []models.Webhook
+	if err := utils.DB(c).Order("id").Find(&hooks).Error; err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to fetch webhooks", nil)
+		return
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching webhooks data", hooks)
+}
+
+// GetWebhookByID godoc
+// @Summary      Get webhook by id
+// @Description  Get a webhook subscription
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Webhook not found"
+// @Router       /webhook [get]
+func GetWebhookByID(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching webhook data", hook)
+}
+
+// CreateWebhook godoc
+// @Summary      Create webhook
+// @Description  Subscribe a URL to event types (* for every event). The secret signing the deliveries is generated when omitted and only returned by this call.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        webhook body dto.CreateWebhookRequest true "Webhook subscription"
+// @Success      201 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Validation error or unknown event type"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Router       /webhook/create [post]
+func CreateWebhook(c *gin.Context) {
+	// get the serializer and validate it
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if err := checkWebhookEvents(req.EventTypes); err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	hook := models.Webhook{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  strings.Join(req.EventTypes, ","),
+		Secret:      req.Secret,
+		Active:      true,
+	}
+	if hook.Secret == "" {
+		hook.Secret = webhooks.NewSecret()
+	}
+
+	// save the webhook
+	if err := utils.DB(c).Create(&hook).Error; err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to create webhook", nil)
+		return
+	}
+
+	// build the reponse
+	utils.Response(c, http.StatusCreated, true, "Webhook created", gin.H{"webhook": hook, "secret": hook.Secret})
+}
+
+// UpdateWebhook godoc
+// @Summary      Update webhook
+// @Description  Change the URL, description, event types or active flag of a webhook. rotateSecret=true replaces the secret and returns the new one.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Param        webhook body dto.UpdateWebhookRequest true "Fields to change"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Validation error or unknown event type"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Webhook not found"
+// @Router       /webhook/update [put]
+func UpdateWebhook(c *gin.Context) {
+	// get the serializer and validate it
+	var req dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if err := checkWebhookEvents(req.EventTypes); err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	// update the webhook, omitted fields are kept
+	if req.URL != "" {
+		hook.URL = req.URL
+	}
+	if req.Description != nil {
+		hook.Description = *req.Description
+	}
+	if len(req.EventTypes) > 0 {
+		hook.EventTypes = strings.Join(req.EventTypes, ",")
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if req.RotateSecret {
+		hook.Secret = webhooks.NewSecret()
+	}
+
+	// save the webhook
+	if err := utils.DB(c).Save(&hook).Error; err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to update webhook", nil)
+		return
+	}
+
+	// build the reponse
+	dataResponse := gin.H{"webhook": hook}
+	if req.RotateSecret {
+		dataResponse["secret"] = hook.Secret
+	}
+	utils.Response(c, http.StatusOK, true, "Webhook updated", dataResponse)
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete webhook
+// @Description  Delete a webhook, its pending deliveries become dead
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Webhook not found"
+// @Router       /webhook/delete [delete]
+func DeleteWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+	if err := utils.DB(c).Delete(&hook).Error; err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to delete webhook", nil)
+		return
+	}
+	utils.Deleted(c, "Webhook deleted", hook)
+}
+
+// PingWebhook godoc
+// @Summary      Ping webhook
+// @Description  Queue a webhook.ping delivery to check that the receiver works and verifies the signature
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Success      202 {object} utils.BaseResponse
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Webhook not found"
+// @Router       /webhook/ping [post]
+func PingWebhook(c *gin.Context) {
+	hook, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+	delivery, err := webhooks.Ping(utils.DB(c), hook)
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to queue ping", nil)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/api/v1/webhook/delivery?id=%d", delivery.ID))
+	utils.Response(c, http.StatusAccepted, true, "Ping queued", delivery)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary      Get webhook deliveries
+// @Description  The delivery log, newest first. Filter with webhook_id, status (pending, succeeded or dead) and event_type, with the operators of the other lists.
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        webhook_id  query  int     false  "Webhook filter"
+// @Param        status      query  string  false  "pending, succeeded or dead"
+// @Param        event_type  query  string  false  "Event type filter"
+// @Param        page        query  int     false  "Page number for pagination"
+// @Param        limit       query  int     false  "Number of items per page"
+// @Param        paginate    query  string  false  "Set to cursor for keyset pagination"
+// @Param        cursor      query  string  false  "Cursor from nextCursor or prevCursor"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      400 {object} utils.BaseResponse "Invalid filter or cursor"
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Router       /webhook/deliveries [get]
+func GetWebhookDeliveries(c *gin.Context) {
+	db, err := utils.ApplyFilters(c, utils.DB(c).Model(&models.WebhookDelivery{}), deliveryFilterFields)
+	if err != nil {
+		utils.Response(c, http.StatusBadRequest, false, err.Error(), nil)
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	page, err := fetchPage(db, c.Request.URL.Query(), deliverySortFields, &deliveries)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching webhook deliveries", page)
+}
+
+// deliveryFilterFields are the columns GetWebhookDeliveries filters on
+var deliveryFilterFields = map[string]string{
+	"webhook_id": "int",
+	"status":     "string",
+	"event_type": "string",
+	"attempts":   "int",
+	"created_at": "date",
+}
+
+// deliverySortFields are the columns GetWebhookDeliveries sorts on
+var deliverySortFields = map[string]bool{"id": true, "created_at": true, "attempts": true, "next_attempt_at": true}
+
+// GetWebhookDelivery godoc
+// @Summary      Get webhook delivery
+// @Description  Get a delivery with its payload and the outcome of its last attempt
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Success      200 {object} utils.BaseResponse
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Delivery not found"
+// @Router       /webhook/delivery [get]
+func GetWebhookDelivery(c *gin.Context) {
+	delivery, ok := findDelivery(c)
+	if !ok {
+		return
+	}
+	utils.Response(c, http.StatusOK, true, "Succes fetching webhook delivery", delivery)
+}
+
+// ReplayWebhookDelivery godoc
+// @Summary      Replay webhook delivery
+// @Description  Queue a copy of a delivery, e.g. a dead one once the receiver is fixed. The copy is signed again and retried like a new delivery.
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   query     integer  true  "id"
+// @Success      202 {object} utils.BaseResponse
+// @Failure      401 {object} utils.BaseResponse "Missing or invalid token"
+// @Failure      403 {object} utils.BaseResponse "Forbidden access"
+// @Failure      404 {object} utils.BaseResponse "Delivery not found"
+// @Router       /webhook/delivery/replay [post]
+func ReplayWebhookDelivery(c *gin.Context) {
+	delivery, ok := findDelivery(c)
+	if !ok {
+		return
+	}
+	replay, err := webhooks.Replay(utils.DB(c), delivery)
+	if err != nil {
+		utils.Response(c, http.StatusInternalServerError, false, "Failed to queue replay", nil)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/api/v1/webhook/delivery?id=%d", replay.ID))
+	utils.Response(c, http.StatusAccepted, true, "Replay queued", replay)
+}
+
+func findWebhook(c *gin.Context) (models.Webhook, bool) {
+	id, ok := utils.ResourceID(c)
+	var hook models.Webhook
+	if err := utils.DB(c).First(&hook, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Webhook not found", nil)
+		return hook, false
+	}
+	return hook, true
+}
+
+func findDelivery(c *gin.Context) (models.WebhookDelivery, bool) {
+	id, ok := utils.ResourceID(c)
+	var delivery models.WebhookDelivery
+	if err := utils.DB(c).First(&delivery, id).Error; !ok || err != nil {
+		utils.Response(c, http.StatusNotFound, false, "Delivery not found", nil)
+		return delivery, false
+	}
+	return delivery, true
+}
+
+// checkWebhookEvents refuses the event types no handler emits
+func checkWebhookEvents(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		known := eventType == "*" || eventType == webhooks.PingEvent
+		for _, t := range events.Types {
+			known = known || t == eventType
+		}
+		if !known {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoder
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register decoder
+)
+
+// ThumbnailSizes are the fixed square sizes generated for every upload
+var ThumbnailSizes = []int{64, 128, 256}
+
+// allowedTypes maps the sniffed MIME type to the file extension
+var allowedTypes = map[string]string{
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ErrUnsupportedType is returned for content other than PNG, GIF or WebP
+var ErrUnsupportedType = errors.New("only PNG, GIF and WebP images are allowed")
+
+// Info describes a validated image
+type Info struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// Inspect sniffs the content type and decodes the image header.
+// The file name and declared content type are never trusted.
+func Inspect(data []byte) (Info, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return Info{}, ErrUnsupportedType
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, fmt.Errorf("invalid image: %w", err)
+	}
+	if "image/"+format != contentType {
+		return Info{}, ErrUnsupportedType
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > 4096 || cfg.Height > 4096 {
+		return Info{}, errors.New("image dimensions must be between 1 and 4096 pixels")
+	}
+
+	return Info{ContentType: contentType, Extension: ext, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// Thumbnail scales the image to fit a size x size transparent PNG canvas.
+// Animated GIFs use their first frame.
+func Thumbnail(data []byte, size int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = max(1, bounds.Dy()*size/bounds.Dx())
+	} else {
+		width = max(1, bounds.Dx()*size/bounds.Dy())
+	}
+
+	// center the scaled image on the canvas
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	offset := image.Pt((size-width)/2, (size-height)/2)
+	target := image.Rectangle{Min: offset, Max: offset.Add(image.Pt(width, height))}
+
+	// pixel art sprites stay crisp when scaled up
+	scaler := draw.Interpolator(draw.CatmullRom)
+	if width > bounds.Dx() {
+		scaler = draw.NearestNeighbor
+	}
+	scaler.Scale(dst, target, src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
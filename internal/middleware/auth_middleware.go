@@ -30,6 +30,21 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
+		c.Next()
+	}
+}
+
+// QueryTokenMiddleware takes the token from the access_token query parameter
+// for the clients that cannot set headers, like EventSource and browser
+// WebSockets
+func QueryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
 		c.Next()
 	}
 }
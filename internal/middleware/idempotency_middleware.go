@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-api/internal/models"
+	"go-api/internal/utils"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyHeaders are the response headers replayed with a stored response
+var idempotencyHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// idempotencyLease is how long a request holds its key, a retry takes the
+// key over once the request did not finish within it, e.g. after a crash
+const idempotencyLease = time.Minute
+
+// idempotencyWriter captures the response so it can be stored
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware stores the response of requests sent with an
+// Idempotency-Key header and replays it when the request is retried. It
+// must run after AuthMiddleware since keys are scoped to the user.
+func IdempotencyMiddleware(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			utils.Response(c, http.StatusBadRequest, false, "Idempotency-Key must be at most 255 characters", nil)
+			c.Abort()
+			return
+		}
+
+		// fingerprint the request
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		// claim the key, the database keeps the microseconds of the claim
+		db := utils.DB(c)
+		now := time.Now().Truncate(time.Microsecond)
+		record := models.IdempotencyKey{
+			UserEmail:   c.GetString("email"),
+			Key:         key,
+			Fingerprint: fingerprint,
+			Status:      models.IdempotencyInFlight,
+			CreatedAt:   now,
+			ClaimedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+		claimed, err := claimIdempotencyKey(db, &record)
+		if err != nil {
+			utils.Response(c, http.StatusInternalServerError, false, "Failed to store Idempotency-Key", nil)
+			c.Abort()
+			return
+		}
+
+		if !claimed {
+			var stored models.IdempotencyKey
+			if err := db.Where("user_email = ? AND key = ?", record.UserEmail, key).First(&stored).Error; err != nil {
+				utils.Response(c, http.StatusConflict, false, "Idempotency-Key is being reused, retry the request", nil)
+				c.Abort()
+				return
+			}
+			replayIdempotent(c, stored, fingerprint)
+			return
+		}
+
+		// run the request and store its response, server errors release
+		// the key so the request can be retried. A retry that took the key
+		// over holds a later claim, this request leaves it alone then.
+		writer := &idempotencyWriter{body: new(bytes.Buffer), ResponseWriter: c.Writer}
+		c.Writer = writer
+		ours := db.Model(&models.IdempotencyKey{}).Where("id = ? AND claimed_at = ?", record.ID, now)
+		completed := false
+		defer func() {
+			if !completed {
+				ours.Session(&gorm.Session{}).Delete(&models.IdempotencyKey{})
+			}
+		}()
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		headers := map[string]string{}
+		for _, name := range idempotencyHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		encoded, _ := json.Marshal(headers)
+		err = ours.Session(&gorm.Session{}).Updates(models.IdempotencyKey{
+			Status:       models.IdempotencyCompleted,
+			StatusCode:   c.Writer.Status(),
+			Headers:      string(encoded),
+			ResponseBody: writer.body.String(),
+		}).Error
+		completed = err == nil
+	}
+}
+
+// claimIdempotencyKey stores the claim of a request, or takes over the key
+// when it expired or when the same request did not finish within the
+// lease. It reports false when another request holds the key.
+func claimIdempotencyKey(db *gorm.DB, record *models.IdempotencyKey) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error == nil, result.Error
+	}
+
+	result = db.Model(&models.IdempotencyKey{}).
+		Where("user_email = ? AND key = ?", record.UserEmail, record.Key).
+		Where("expires_at < ? OR (status = ? AND fingerprint = ? AND (claimed_at IS NULL OR claimed_at < ?))",
+			record.ClaimedAt, models.IdempotencyInFlight, record.Fingerprint, record.ClaimedAt.Add(-idempotencyLease)).
+		Updates(map[string]interface{}{
+			"fingerprint":   record.Fingerprint,
+			"status":        models.IdempotencyInFlight,
+			"status_code":   0,
+			"headers":       "",
+			"response_body": "",
+			"created_at":    record.CreatedAt,
+			"claimed_at":    record.ClaimedAt,
+			"expires_at":    record.ExpiresAt,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	var taken models.IdempotencyKey
+	err := db.Select("id").Where("user_email = ? AND key = ?", record.UserEmail, record.Key).First(&taken).Error
+	record.ID = taken.ID
+	return err == nil, err
+}
+
+// SweepIdempotencyKeys deletes the expired keys every interval until ctx is
+// done
+func SweepIdempotencyKeys(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := sweepIdempotencyKeys(db, time.Now()); err != nil {
+			log.Printf("idempotency: failed to delete the expired keys: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepIdempotencyKeys deletes in batches so a backlog does not hold a long
+// lock on the table
+func sweepIdempotencyKeys(db *gorm.DB, now time.Time) error {
+	const batch = 1000
+	for {
+		expired := db.Model(&models.IdempotencyKey{}).Select("id").Where("expires_at < ?", now).Limit(batch)
+		result := db.Where("id IN (?)", expired).Delete(&models.IdempotencyKey{})
+		if result.Error != nil || result.RowsAffected < batch {
+			return result.Error
+		}
+	}
+}
+
+// replayIdempotent answers a retry with the stored response
+func replayIdempotent(c *gin.Context, stored models.IdempotencyKey, fingerprint string) {
+	defer c.Abort()
+	if stored.Fingerprint != fingerprint {
+		utils.Response(c, http.StatusConflict, false, "Idempotency-Key was already used with a different request", nil)
+		return
+	}
+	if stored.Status != models.IdempotencyCompleted {
+		c.Header("Retry-After", "1")
+		utils.Response(c, http.StatusConflict, false, "A request with this Idempotency-Key is still in progress", nil)
+		return
+	}
+
+	var headers map[string]string
+	json.Unmarshal([]byte(stored.Headers), &headers)
+	for name, value := range headers {
+		c.Header(name, value)
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(stored.StatusCode, headers["Content-Type"], []byte(stored.ResponseBody))
+}
@@ -3,37 +3,106 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go-api/config"
 	"go-api/internal/models"
+	"gorm.io/gorm"
 )
 
 // Custom response writer to capture response body
 type bodyWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body *bodyCapture
 }
 
 func (w bodyWriter) Write(b []byte) (int, error) {
-	w.body.Write(b) // Capture the response
+	// Streams and binary bodies are not captured
+	if loggable(w.Header().Get("Content-Type")) {
+		w.body.Write(b) // Capture the response
+	}
 	return w.ResponseWriter.Write(b) // Write response as normal
 }
 
+// Unwrap lets http.ResponseController reach the connection, e.g. to set
+// the write deadlines of a stream
+func (w bodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// bodyCapture keeps a body up to limit bytes, a larger one is not logged
+type bodyCapture struct {
+	buf      bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (c *bodyCapture) Write(b []byte) {
+	if c.overflow {
+		return
+	}
+	if c.buf.Len()+len(b) > c.limit {
+		c.overflow = true
+		c.buf = bytes.Buffer{}
+		return
+	}
+	c.buf.Write(b)
+}
+
+// String is the body to store, redacted
+func (c *bodyCapture) String() string {
+	if c.overflow {
+		return fmt.Sprintf("(over %d bytes, not logged)", c.limit)
+	}
+	// Postgres text refuses NUL bytes and invalid UTF-8
+	body := strings.ToValidUTF8(strings.ReplaceAll(c.buf.String(), "\x00", ""), "\uFFFD")
+	return RedactBody(body)
+}
+
+// loggable reports whether a body of the content type is text worth
+// logging, event streams grow for as long as they are open
+func loggable(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/event-stream":
+		return false
+	case "", "application/json", "application/xml", "application/graphql", "application/x-www-form-urlencoded":
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// UnmatchedRoute is the route logged for the requests matching no route
+const UnmatchedRoute = "(unmatched)"
+
 // LoggerMiddleware logs and stores request/response details
 func LoggerMiddleware() gin.HandlerFunc {
+	maxBody := config.GetEnvInt("LOG_BODY_MAX_BYTES", 64<<10) // Default 64 KiB
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		// Read request body
-		reqBody, _ := io.ReadAll(c.Request.Body)
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(reqBody))
+		// Read the start of a text request body, the handler reads it again
+		reqBody := &bodyCapture{limit: maxBody}
+		if loggable(c.GetHeader("Content-Type")) {
+			head, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBody)+1))
+			reqBody.Write(head)
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+		}
 
 		// Wrap response writer
-		bw := &bodyWriter{body: new(bytes.Buffer), ResponseWriter: c.Writer}
+		bw := &bodyWriter{body: &bodyCapture{limit: maxBody}, ResponseWriter: c.Writer}
 		c.Writer = bw
 
 		c.Next()
@@ -52,17 +121,134 @@ func LoggerMiddleware() gin.HandlerFunc {
 			method, path, ip, status, duration,
 		)
 
+		// the route template keeps the usage analytics to one row per endpoint
+		route := c.FullPath()
+		if route == "" {
+			route = UnmatchedRoute
+		}
+
 		logEntry := models.Log{
+			RequestID:    c.GetString("requestID"),
+			UserEmail:    c.GetString("email"),
 			Method:       c.Request.Method,
-			URI:          c.Request.RequestURI,
+			URI:          redactToken(c.Request.URL),
+			Route:        route,
 			ClientIP:     c.ClientIP(),
 			StatusCode:   c.Writer.Status(),
 			Duration:     duration.String(),
-			RequestBody:  string(reqBody),
+			DurationMs:   float64(duration.Microseconds()) / 1000,
+			RequestBody:  reqBody.String(),
 			ResponseBody: bw.body.String(),
 			CreatedAt:    time.Now(),
 		}
 
-		config.DB.Create(&logEntry)
+		if err := config.DB.Create(&logEntry).Error; err != nil {
+			log.Printf("failed to store the log of %s %s: %v", method, path, err)
+		}
+	}
+}
+
+// redactToken hides the access_token of the URI so logs do not leak tokens
+func redactToken(uri *url.URL) string {
+	query := uri.Query()
+	if query.Get("access_token") == "" {
+		return uri.RequestURI()
+	}
+	query.Set("access_token", "REDACTED")
+	redacted := *uri
+	redacted.RawQuery = query.Encode()
+	return redacted.RequestURI()
+}
+
+// Redacted replaces the secrets in the stored bodies
+const Redacted = "REDACTED"
+
+// RedactBody hides the passwords, tokens and secrets of a JSON or form
+// body before it is stored, other bodies are returned as they are
+func RedactBody(body string) string {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return redactForm(body)
+	}
+	if !redactValue(value) {
+		return body
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return body
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func redactValue(value interface{}) bool {
+	redacted := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if isSecret(key) {
+				v[key] = Redacted
+				redacted = true
+				continue
+			}
+			redacted = redactValue(inner) || redacted
+		}
+	case []interface{}:
+		for _, inner := range v {
+			redacted = redactValue(inner) || redacted
+		}
+	}
+	return redacted
+}
+
+// redactForm redacts a url encoded form, such as a token request
+func redactForm(body string) string {
+	form, err := url.ParseQuery(body)
+	if err != nil || !strings.Contains(body, "=") {
+		return body
+	}
+	redacted := false
+	for key := range form {
+		if isSecret(key) {
+			form[key] = []string{Redacted}
+			redacted = true
+		}
 	}
+	if !redacted {
+		return body
+	}
+	return form.Encode()
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "token") || strings.Contains(key, "secret")
+}
+
+// RedactStoredLogs redacts the bodies of the requests logged before the
+// logger redacted them, the rows already redacted are skipped
+func RedactStoredLogs(db *gorm.DB) error {
+	mentions := "(lower(request_body) LIKE '%password%' OR lower(request_body) LIKE '%token%' OR lower(request_body) LIKE '%secret%'" +
+		" OR lower(response_body) LIKE '%password%' OR lower(response_body) LIKE '%token%' OR lower(response_body) LIKE '%secret%')"
+	var entries []models.Log
+	return db.Select("id, request_body, response_body").
+		Where(mentions).
+		Where("request_body NOT LIKE ? AND response_body NOT LIKE ?", "%"+Redacted+"%", "%"+Redacted+"%").
+		FindInBatches(&entries, 500, func(tx *gorm.DB, batch int) error {
+			for _, entry := range entries {
+				request, response := RedactBody(entry.RequestBody), RedactBody(entry.ResponseBody)
+				if request == entry.RequestBody && response == entry.ResponseBody {
+					continue
+				}
+				err := db.Model(&models.Log{}).Where("id = ?", entry.ID).
+					Updates(map[string]interface{}{"request_body": request, "response_body": response}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
 }
@@ -0,0 +1,54 @@
+package middleware
+
+import "testing"
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"email":"a@b.co","password":"hunter2"}`, `{"email":"a@b.co","password":"REDACTED"}`},
+		{`{"data":{"token":"eyJhbGciOi"},"items":[{"apiSecret":"s","n":12345678901234567}]}`, `{"data":{"token":"REDACTED"},"items":[{"apiSecret":"REDACTED","n":12345678901234567}]}`},
+		{`{"name":"<b>ash</b>"}`, `{"name":"<b>ash</b>"}`},
+		{`grant_type=password&password=hunter2&username=ash`, `grant_type=password&password=REDACTED&username=ash`},
+		{`plain text with a password in it`, `plain text with a password in it`},
+		{``, ``},
+	}
+	for _, tt := range tests {
+		if got := RedactBody(tt.body); got != tt.want {
+			t.Errorf("RedactBody(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestLoggable(t *testing.T) {
+	tests := map[string]bool{
+		"":                                  true,
+		"application/json; charset=utf-8":   true,
+		"application/merge-patch+json":      true,
+		"application/x-www-form-urlencoded": true,
+		"text/csv":                          true,
+		"text/event-stream":                 false,
+		"image/png":                         false,
+		"multipart/form-data; boundary=x":   false,
+		"application/octet-stream":          false,
+	}
+	for contentType, want := range tests {
+		if got := loggable(contentType); got != want {
+			t.Errorf("loggable(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
+
+func TestBodyCapture(t *testing.T) {
+	body := &bodyCapture{limit: 40}
+	body.Write([]byte(`{"password":"hunter2",`))
+	body.Write([]byte("\"n\":\"a\x00b\xff\"}"))
+	if got, want := body.String(), `{"n":"ab`+"�"+`","password":"REDACTED"}`; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	body.Write([]byte("more than the limit"))
+	if got := body.String(); got != "(over 40 bytes, not logged)" {
+		t.Errorf("String = %q after the limit", got)
+	}
+}
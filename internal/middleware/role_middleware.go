@@ -37,17 +37,13 @@ func RoleMiddleware(requiredRole string) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		
-		// ACL Logic
-		userRank := roleRank[userRole]
-		requiredRank := roleRank[requiredRole]
 
 		// build response
 		roles := roles{
 			RequiredRole: requiredRole,
 			UserRole:     userRole,
 		}
-		if userRank < requiredRank {
+		if !HasRole(userRole, requiredRole) {
 			utils.Response(c, http.StatusForbidden, false, "Forbidden: Insufficient permissions", roles)
 			c.Abort()
 			return
@@ -56,3 +52,8 @@ func RoleMiddleware(requiredRole string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// HasRole reports whether userRole ranks at least as high as requiredRole
+func HasRole(userRole, requiredRole string) bool {
+	return roleRank[userRole] >= roleRank[requiredRole]
+}
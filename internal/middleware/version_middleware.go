@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionMiddleware tags the request with the API version of its route tree
+func APIVersionMiddleware(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("apiVersion", version)
+		c.Next()
+	}
+}
+
+// DeprecationMiddleware marks a route as deprecated in favor of its successor
+func DeprecationMiddleware(deprecatedAt, sunset time.Time, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+		c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		c.Next()
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// UserAchievement is a badge awarded to a user
+type UserAchievement struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserEmail string    `json:"userEmail" gorm:"uniqueIndex:idx_user_achievement"`
+	Code      string    `json:"code" gorm:"uniqueIndex:idx_user_achievement"`
+	AwardedAt time.Time `json:"awardedAt"`
+}
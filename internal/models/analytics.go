@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// AnalyticsFavoriteDay counts the favorites of a species created on a day
+// and still kept, the analytics read it instead of the favorites
+type AnalyticsFavoriteDay struct {
+	Day       time.Time `json:"day" gorm:"primaryKey;type:date"`
+	Name      string    `json:"name" gorm:"primaryKey"`
+	Types     string    `json:"types" gorm:"primaryKey"` // slash separated, from the species when known
+	Favorites int64     `json:"favorites"`
+}
+
+// AnalyticsUserDay counts the favorites a user created on a day
+type AnalyticsUserDay struct {
+	Day       time.Time `json:"day" gorm:"primaryKey;type:date"`
+	UserEmail string    `json:"userEmail" gorm:"primaryKey"`
+	Favorites int64     `json:"favorites"`
+}
+
+// AnalyticsRollup tracks the refreshes of a rollup
+type AnalyticsRollup struct {
+	Name        string    `json:"name" gorm:"primaryKey"`
+	Watermark   time.Time `json:"watermark"` // changes before it are rolled up
+	RefreshedAt time.Time `json:"refreshedAt"`
+	Days        int       `json:"days"` // days recomputed by the last refresh, hours for the usage rollups
+}
@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// Box is a named collection of favorites with a capacity
+type Box struct {
+	gorm.Model
+	UserEmail string    `json:"userEmail" gorm:"uniqueIndex:idx_box_user_name"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_box_user_name"`
+	Capacity  int       `json:"capacity"`
+	Pokemons  []Pokemon `json:"pokemons,omitempty" gorm:"many2many:box_pokemons"`
+}
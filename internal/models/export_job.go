@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Export job statuses
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+// ExportJob is an export running in the background
+type ExportJob struct {
+	gorm.Model
+	UserEmail  string     `json:"userEmail" gorm:"index"`
+	Resource   string     `json:"resource"` // pokemons or users
+	Format     string     `json:"format"`
+	Query      string     `json:"query"` // encoded filters
+	Status     string     `json:"status" gorm:"index"`
+	Rows       int64      `json:"rows"`
+	Key        string     `json:"-"` // blob store key of the result
+	Error      string     `json:"error,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Idempotency key statuses
+const (
+	IdempotencyInFlight  = "in_flight"
+	IdempotencyCompleted = "completed"
+)
+
+// IdempotencyKey is the stored response of a request sent with an
+// Idempotency-Key header, keys are scoped to the user
+type IdempotencyKey struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserEmail    string    `json:"userEmail" gorm:"uniqueIndex:idx_idempotency_user_key"`
+	Key          string    `json:"key" gorm:"uniqueIndex:idx_idempotency_user_key"`
+	Fingerprint  string    `json:"fingerprint"` // hash of the method, path and body
+	Status       string    `json:"status"`
+	StatusCode   int       `json:"statusCode"`
+	Headers      string    `json:"headers"` // JSON encoded replayed headers
+	ResponseBody string    `json:"responseBody"`
+	CreatedAt    time.Time `json:"createdAt"`
+	ClaimedAt    time.Time `json:"claimedAt"` // start of the request running with the key
+	ExpiresAt    time.Time `json:"expiresAt" gorm:"index"`
+}
@@ -5,13 +5,21 @@ import "time"
 // models/request_log.go
 type Log struct {
 	ID           uint      `gorm:"primaryKey"`
+	RequestID    string    `json:"request_id" gorm:"index"` // correlation id, answered in X-Request-ID
 	Username     *uint     `json:"username"`
+	UserEmail    string    `json:"user_email" gorm:"index"`
 	Method       string    `json:"method"`
 	URI          string    `json:"uri"`
-	ClientIP     string    `json:"client_ip"`
+	Route        string    `json:"route"` // template of the matched route, e.g. /api/v2/pokemons/:id
+	ClientIP     string    `json:"client_ip" gorm:"index"`
 	StatusCode   int       `json:"status_code"`
 	Duration     string    `json:"duration"`
+	DurationMs   float64   `json:"duration_ms"`
 	RequestBody  string    `json:"request_body"`
 	ResponseBody string    `json:"response_body"`
-	CreatedAt    time.Time `json:"created_at"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
 }
+
+// LogSearchVector is the full-text document of a log entry, the first 100k
+// characters of its bodies. The GIN index of the logs is built on it.
+const LogSearchVector = "to_tsvector('simple', left(coalesce(request_body, '') || ' ' || coalesce(response_body, ''), 100000))"
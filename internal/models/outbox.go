@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event written in the transaction of the change it
+// describes, the relay publishes it once the transaction is committed
+type OutboxEvent struct {
+	ID            uint       `json:"id" gorm:"primarykey"` // publication order
+	CreatedAt     time.Time  `json:"createdAt"`
+	AggregateType string     `json:"aggregateType" gorm:"index:idx_outbox_aggregate"`
+	AggregateID   uint       `json:"aggregateId" gorm:"index:idx_outbox_aggregate"`
+	EventType     string     `json:"eventType"`
+	UserEmail     string     `json:"userEmail"`
+	Payload       string     `json:"payload"` // JSON encoded
+	OccurredAt    time.Time  `json:"occurredAt"`
+	PublishedAt   *time.Time `json:"publishedAt" gorm:"index"`
+	PublishedTo   string     `json:"publishedTo"`               // comma separated sinks already done
+	PublishedSeq  *uint64    `json:"publishedSeq" gorm:"index"` // publication order, followed by every instance
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	LastError     string     `json:"lastError,omitempty"`
+}
+
+// OutboxLease elects the instance that relays the outbox, the holder
+// renews it while running and another instance takes over once it expires
+type OutboxLease struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -10,4 +10,17 @@ type Pokemon struct {
 	Type      string `json:"type"`
 	Notes     string `json:"notes"`
 	Sprite    string `json:"sprite"`
+	Shiny     bool   `json:"shiny"`
+	Form      string `json:"form"`
+	Gender    string `json:"gender"`
+	Version   uint   `json:"version" gorm:"not null;default:1"`
+	Tags      []Tag  `json:"tags" gorm:"many2many:pokemon_tags"`
+}
+
+// BeforeCreate starts the optimistic locking version
+func (p *Pokemon) BeforeCreate(tx *gorm.DB) error {
+	if p.Version == 0 {
+		p.Version = 1
+	}
+	return nil
 }
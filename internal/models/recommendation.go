@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SpeciesNeighbor is a species often favorited along another one, the
+// recommendation job keeps the closest neighbors of each species
+type SpeciesNeighbor struct {
+	SpeciesID  uint      `json:"speciesId" gorm:"primaryKey;autoIncrement:false"`
+	NeighborID uint      `json:"neighborId" gorm:"primaryKey;autoIncrement:false"`
+	Score      float64   `json:"score"`    // cosine similarity of their trainers
+	Together   int64     `json:"together"` // trainers who favorited both
+	Rank       int       `json:"rank"`     // 1 for the closest neighbor
+	ComputedAt time.Time `json:"computedAt"`
+}
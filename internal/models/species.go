@@ -0,0 +1,30 @@
+package models
+
+import "strings"
+
+// Species is a catalog entry describing the variants a pokemon allows
+type Species struct {
+	ID                uint   `json:"id" gorm:"primaryKey;autoIncrement:false"` // national dex number
+	Name              string `json:"name" gorm:"uniqueIndex"`
+	Types             string `json:"types"` // slash separated, e.g. "grass/poison"
+	Forms             string `json:"forms"` // comma separated, e.g. "alolan,galarian"
+	Genderless        bool   `json:"genderless"`
+	GenderDifferences bool   `json:"genderDifferences"`
+	Gigantamax        bool   `json:"gigantamax"`
+}
+
+// AllowsForm reports whether the species has the given form
+func (s *Species) AllowsForm(form string) bool {
+	if form == "" {
+		return true
+	}
+	if form == "gigantamax" {
+		return s.Gigantamax
+	}
+	for _, f := range strings.Split(s.Forms, ",") {
+		if strings.TrimSpace(f) == form {
+			return true
+		}
+	}
+	return false
+}
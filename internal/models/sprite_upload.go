@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+// SpriteUpload is a custom sprite uploaded for a favorite
+type SpriteUpload struct {
+	gorm.Model
+	UserEmail   string `json:"userEmail"`
+	PokemonID   uint   `json:"pokemonId" gorm:"index"`
+	Key         string `json:"key"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "gorm.io/gorm"
+
+// Tag is a user defined label attached to favorites
+type Tag struct {
+	gorm.Model
+	UserEmail string `json:"userEmail" gorm:"uniqueIndex:idx_tag_user_name"`
+	Name      string `json:"name" gorm:"uniqueIndex:idx_tag_user_name"`
+}
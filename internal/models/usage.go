@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RequestHour rolls up the requests of an endpoint in an hour
+type RequestHour struct {
+	Hour          time.Time `json:"hour" gorm:"primaryKey"`
+	Method        string    `json:"method" gorm:"primaryKey"`
+	Route         string    `json:"route" gorm:"primaryKey"`
+	Requests      int64     `json:"requests"`
+	ClientErrors  int64     `json:"clientErrors"` // 4xx
+	ServerErrors  int64     `json:"serverErrors"` // 5xx
+	DurationMs    float64   `json:"durationMs"`   // summed
+	MaxDurationMs float64   `json:"maxDurationMs"`
+	Latency       string    `json:"latency"` // latency histogram, see usage.Histogram
+}
+
+// ClientHour counts the requests of a client, by IP or by user, in an hour
+type ClientHour struct {
+	Hour     time.Time `json:"hour" gorm:"primaryKey"`
+	Kind     string    `json:"kind" gorm:"primaryKey"` // ip or user
+	Client   string    `json:"client" gorm:"primaryKey"`
+	Requests int64     `json:"requests"`
+	Errors   int64     `json:"errors"` // 4xx and 5xx
+}
@@ -11,6 +11,15 @@ type User struct {
 	Email    string `json:"email" gorm:"unique"`
 	Password string `json:"-"`
 	Role     string `json:"role"`
+	Version  uint   `json:"version" gorm:"not null;default:1"`
+}
+
+// BeforeCreate starts the optimistic locking version
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.Version == 0 {
+		u.Version = 1
+	}
+	return nil
 }
 
 func (u *User) HashPassword(password string) error {
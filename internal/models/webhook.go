@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook is an endpoint notified of the events it subscribes to
+type Webhook struct {
+	gorm.Model
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	EventTypes  string `json:"eventTypes"` // comma separated, * for every event
+	Secret      string `json:"-"`          // signs the deliveries
+	Active      bool   `json:"active" gorm:"not null;default:true"`
+}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery is an event queued for a webhook, with the outcome of its
+// last attempt
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	WebhookID      uint       `json:"webhookId" gorm:"index"`
+	EventType      string     `json:"eventType" gorm:"index"`
+	Payload        string     `json:"payload"` // signed JSON body
+	Status         string     `json:"status" gorm:"index:idx_delivery_due"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt" gorm:"index:idx_delivery_due"`
+	LastStatusCode int        `json:"lastStatusCode"`
+	LastError      string     `json:"lastError,omitempty"`
+	LastResponse   string     `json:"lastResponse,omitempty"` // start of the receiver's body
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+	ReplayOf       *uint      `json:"replayOf,omitempty"`
+}
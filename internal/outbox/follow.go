@@ -0,0 +1,118 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Follow hands the events published by the relay, whichever instance runs
+// it, to the sinks of this instance in publication order until ctx is
+// done. It starts with the events published since the process started,
+// before the local subscribers loaded their state, so they may get an event
+// they already saw. A failed event is retried with the backoff of the relay
+// and holds back the next ones.
+func Follow(ctx context.Context, db *gorm.DB, s Settings, sinks ...Sink) {
+	f := follower{db: db.WithContext(ctx), s: s, sinks: sinks}
+	for {
+		err := f.start()
+		if err == nil {
+			break
+		}
+		log.Printf("outbox: failed to follow: %v", err)
+		if !sleep(ctx, s.PollInterval) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		full, err := f.next(ctx)
+		if err != nil {
+			failures++
+			log.Printf("outbox: failed to follow: %v", err)
+			if !sleep(ctx, backoff(s, failures)) {
+				return
+			}
+			continue
+		}
+		failures = 0
+		if full && ctx.Err() == nil {
+			continue // more events are waiting
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-published:
+		}
+	}
+}
+
+// follower is the position of an instance in the published events
+type follower struct {
+	db     *gorm.DB
+	s      Settings
+	sinks  []Sink
+	cursor uint64 // sequence of the last event handed to every sink
+	done   string // sinks that got the event after the cursor
+}
+
+// started is when the process started
+var started = time.Now()
+
+// start places the cursor after the last event published before the
+// process started
+func (f *follower) start() error {
+	var last *uint64
+	err := f.db.Model(&models.OutboxEvent{}).
+		Select("MAX(published_seq)").
+		Where("published_at < ?", started).
+		Scan(&last).Error
+	if err == nil && last != nil {
+		f.cursor = *last
+	}
+	return err
+}
+
+// next hands a batch of published events to the sinks, it reports whether
+// the batch was full
+func (f *follower) next(ctx context.Context) (bool, error) {
+	var batch []models.OutboxEvent
+	err := f.db.Where("published_seq > ?", f.cursor).
+		Order("published_seq").
+		Limit(f.s.BatchSize).
+		Find(&batch).Error
+	if err != nil {
+		return false, err
+	}
+	for _, event := range batch {
+		// the sinks done are those of this instance, not the relay's
+		event.PublishedTo = f.done
+		done, err := publish(ctx, event, f.sinks)
+		if err != nil {
+			f.done = done
+			return false, err
+		}
+		f.cursor, f.done = *event.PublishedSeq, ""
+	}
+	return len(batch) == f.s.BatchSize, nil
+}
+
+// sleep waits for d, it reports false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
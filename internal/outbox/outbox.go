@@ -0,0 +1,73 @@
+// Package outbox makes the domain events as durable as the changes they
+// describe. Handlers write the event in the transaction of the change with
+// Record, and the relay publishes the committed events to the sinks. The
+// relay runs on one instance at a time, Follow hands the published events
+// to the local subscribers of every instance.
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"go-api/internal/events"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Aggregate types, the events of one aggregate are published in order
+const (
+	Pokemon = "pokemon"
+	User    = "user"
+	Box     = "box"
+)
+
+// wakeup makes the relay scan the outbox without waiting for the poll, and
+// published makes Follow read the events the relay just published
+var (
+	wakeup    = make(chan struct{}, 1)
+	published = make(chan struct{}, 1)
+)
+
+// Record writes the event of a change to the aggregate, tx must be the
+// transaction of the change. Write the change first: its row lock orders
+// the events of concurrent changes to the same aggregate.
+func Record(tx *gorm.DB, aggregate string, id uint, e events.Event) error {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEvent{
+		AggregateType: aggregate,
+		AggregateID:   id,
+		EventType:     e.Type,
+		UserEmail:     e.UserEmail,
+		Payload:       string(payload),
+		OccurredAt:    e.OccurredAt,
+	}).Error
+}
+
+// Transaction runs fn in a transaction and wakes the relay once it is
+// committed
+func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if err := db.Transaction(fn); err != nil {
+		return err
+	}
+	Notify()
+	return nil
+}
+
+// Notify wakes the relay, call it after committing recorded events
+func Notify() {
+	signal(wakeup)
+}
+
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
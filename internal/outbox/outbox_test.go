@@ -0,0 +1,226 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go-api/internal/events"
+	"go-api/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testSettings = Settings{
+	BatchSize:    10,
+	PollInterval: 20 * time.Millisecond,
+	Backoff:      20 * time.Millisecond,
+	MaxBackoff:   80 * time.Millisecond,
+	Lease:        10 * time.Second,
+	Retention:    time.Hour,
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/outbox.db"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.OutboxEvent{}, &models.OutboxLease{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func record(t *testing.T, db *gorm.DB, aggregate string, id uint, eventType string) {
+	t.Helper()
+	err := Record(db, aggregate, id, events.Event{Type: eventType, Payload: map[string]uint{"id": id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// run relays the outbox until the test ends
+func run(t *testing.T, db *gorm.DB, sinks ...Sink) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go Start(ctx, db, testSettings, sinks...)
+}
+
+// eventually waits for cond, the relay runs in the background
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func kafkaKeys(k *MemoryKafka) []string {
+	var keys []string
+	for _, m := range k.Messages() {
+		var message Message
+		json.Unmarshal(m.Value, &message)
+		keys = append(keys, string(m.Key)+" "+message.Type)
+	}
+	return keys
+}
+
+func TestRelayPublishesInOrder(t *testing.T) {
+	db := newTestDB(t)
+	kafka, nats := &MemoryKafka{}, &MemoryNATS{}
+	record(t, db, Pokemon, 1, events.PokemonCreated)
+	record(t, db, Pokemon, 1, events.PokemonUpdated)
+	record(t, db, Box, 2, events.BoxCreated)
+	record(t, db, Pokemon, 1, events.PokemonDeleted)
+	run(t, db, KafkaSink{Writer: kafka, Topic: "pokeapi"}, NATSSink{Conn: nats, Prefix: "pokeapi."})
+
+	eventually(t, func() bool { return len(nats.Messages()) == 4 })
+	want := []string{"pokemon:1 pokemon.created", "pokemon:1 pokemon.updated", "box:2 box.created", "pokemon:1 pokemon.deleted"}
+	if got := kafkaKeys(kafka); len(got) != len(want) {
+		t.Fatalf("kafka got %v, want %v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("kafka got %v, want %v", got, want)
+				break
+			}
+		}
+	}
+	if subject := nats.Messages()[0].Subject; subject != "pokeapi.pokemon.created" {
+		t.Errorf("subject = %q", subject)
+	}
+
+	var seqs []uint64
+	db.Model(&models.OutboxEvent{}).Order("id").Pluck("published_seq", &seqs)
+	for i, seq := range seqs {
+		if seq != uint64(i+1) {
+			t.Errorf("published_seq = %v, want 1, 2, 3, 4", seqs)
+			break
+		}
+	}
+}
+
+func TestRelayRetriesFailedSinksAlone(t *testing.T) {
+	db := newTestDB(t)
+	kafka, nats := &MemoryKafka{}, &MemoryNATS{}
+	nats.Fail(errors.New("nats is down"))
+	record(t, db, Pokemon, 1, events.PokemonCreated)
+	record(t, db, Pokemon, 1, events.PokemonUpdated)
+	run(t, db, KafkaSink{Writer: kafka, Topic: "pokeapi"}, NATSSink{Conn: nats, Prefix: "pokeapi."})
+
+	eventually(t, func() bool {
+		var event models.OutboxEvent
+		db.First(&event)
+		return event.Attempts >= 2 && event.LastError != ""
+	})
+	// the later event of the aggregate waits for the first one
+	if got := kafkaKeys(kafka); len(got) != 1 {
+		t.Fatalf("kafka got %v, want only the first event", got)
+	}
+
+	nats.Fail(nil)
+	eventually(t, func() bool { return len(nats.Messages()) == 2 })
+	// kafka took the first event already, it is not written again
+	if got := kafkaKeys(kafka); len(got) != 2 || got[1] != "pokemon:1 pokemon.updated" {
+		t.Errorf("kafka got %v", got)
+	}
+}
+
+func TestRelayDoesNotHoldBackOtherAggregates(t *testing.T) {
+	db := newTestDB(t)
+	failing := Bus{Subscription: subscription("flaky", func(e events.Event) error {
+		var payload struct{ ID uint }
+		json.Unmarshal(e.Payload.(json.RawMessage), &payload)
+		if payload.ID == 1 {
+			return errors.New("not now")
+		}
+		return nil
+	})}
+	nats := &MemoryNATS{}
+	record(t, db, Pokemon, 1, events.PokemonCreated)
+	record(t, db, Pokemon, 2, events.PokemonCreated)
+	run(t, db, NATSSink{Conn: nats, Prefix: "pokeapi."}, failing)
+
+	eventually(t, func() bool {
+		var published int64
+		db.Model(&models.OutboxEvent{}).Where("published_at IS NOT NULL").Count(&published)
+		return published == 1
+	})
+	var event models.OutboxEvent
+	db.Where("aggregate_id = ?", 1).First(&event)
+	if event.PublishedAt != nil || event.PublishedTo != "nats" || event.LastError != "bus:flaky: not now" {
+		t.Errorf("failed event = %+v", event)
+	}
+}
+
+func TestFollowHandsPublishedEventsToEveryInstance(t *testing.T) {
+	db := newTestDB(t)
+	record(t, db, Pokemon, 1, events.PokemonCreated)
+	run(t, db)
+	eventually(t, func() bool {
+		var published int64
+		db.Model(&models.OutboxEvent{}).Where("published_at IS NOT NULL").Count(&published)
+		return published == 1
+	})
+
+	// two instances start now, one of them fails once
+	defer func(t time.Time) { started = t }(started)
+	started = time.Now()
+	got := [2]chan string{make(chan string, 10), make(chan string, 10)}
+	failed := false
+	for i := range got {
+		i := i
+		sink := Bus{Subscription: subscription("feed", func(e events.Event) error {
+			if i == 1 && !failed {
+				failed = true
+				return errors.New("not now")
+			}
+			got[i] <- e.Type
+			return nil
+		})}
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go Follow(ctx, db, testSettings, sink)
+	}
+
+	record(t, db, Pokemon, 1, events.PokemonUpdated)
+	record(t, db, Pokemon, 1, events.PokemonDeleted)
+	Notify()
+	for i := range got {
+		for _, want := range []string{events.PokemonUpdated, events.PokemonDeleted} {
+			select {
+			case eventType := <-got[i]:
+				if eventType != want {
+					t.Errorf("instance %d got %s, want %s", i, eventType, want)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatalf("instance %d did not get %s", i, want)
+			}
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := Settings{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempts, want := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := backoff(s, attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+// subscription registers a handler of every event type and returns its
+// subscription, the relay of the tests only calls it through a Bus
+func subscription(name string, h events.Handler) events.Subscription {
+	events.Subscribe(name, h, events.Types...)
+	subscriptions := events.Subscriptions()
+	return subscriptions[len(subscriptions)-1]
+}
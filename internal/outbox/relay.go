@@ -0,0 +1,209 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go-api/config"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Settings tune the relay
+type Settings struct {
+	BatchSize    int           // events read per scan
+	PollInterval time.Duration // between two scans of the outbox
+	Backoff      time.Duration // delay after the first failure, doubled after each one
+	MaxBackoff   time.Duration
+	Lease        time.Duration // an instance relays alone for this long, renewed at each scan
+	Retention    time.Duration // published events are kept this long
+}
+
+// LoadSettings reads the settings from the environment
+func LoadSettings() Settings {
+	return Settings{
+		BatchSize:    config.GetEnvInt("OUTBOX_BATCH_SIZE", 100),                 // Default 100 events
+		PollInterval: config.GetEnvDuration("OUTBOX_POLL_INTERVAL", time.Second), // Default poll
+		Backoff:      config.GetEnvDuration("OUTBOX_BACKOFF", time.Second),       // Default 1s, 2s, 4s...
+		MaxBackoff:   config.GetEnvDuration("OUTBOX_MAX_BACKOFF", 5*time.Minute), // Default cap
+		Lease:        config.GetEnvDuration("OUTBOX_LEASE", 30*time.Second),      // Default lease
+		Retention:    config.GetEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),  // Default 7 days
+	}
+}
+
+// the name of the lease row
+const relayLease = "relay"
+
+// Start publishes the committed events to the sinks until ctx is done.
+// Failed events are retried until every sink accepted them, and the later
+// events of their aggregate wait meanwhile. With several instances, the one
+// holding the lease relays so the order is kept.
+func Start(ctx context.Context, db *gorm.DB, s Settings, sinks ...Sink) {
+	holder := newHolder()
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	var pruned time.Time
+	for {
+		full := false
+		if until, ok := acquire(db, holder, s.Lease); ok {
+			var err error
+			if full, err = relay(ctx, db, s, sinks, until); err != nil {
+				log.Printf("outbox: failed to relay: %v", err)
+			}
+			if time.Since(pruned) > time.Hour {
+				pruned = time.Now()
+				db.Where("published_at < ?", pruned.Add(-s.Retention)).Delete(&models.OutboxEvent{})
+			}
+		}
+		if full && ctx.Err() == nil {
+			continue // more events are waiting
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wakeup:
+		}
+	}
+}
+
+// acquire takes or renews the lease, it returns when the holder must stop
+// relaying to leave a margin before the lease expires
+func acquire(db *gorm.DB, holder string, lease time.Duration) (time.Time, bool) {
+	now := time.Now()
+	created := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.OutboxLease{Name: relayLease, Holder: holder, ExpiresAt: now.Add(lease)})
+	if created.Error == nil && created.RowsAffected == 0 {
+		created = db.Model(&models.OutboxLease{}).
+			Where("name = ? AND (holder = ? OR expires_at < ?)", relayLease, holder, now).
+			Updates(map[string]interface{}{"holder": holder, "expires_at": now.Add(lease)})
+	}
+	if created.Error != nil {
+		log.Printf("outbox: failed to take the lease: %v", created.Error)
+		return now, false
+	}
+	return now.Add(lease / 2), created.RowsAffected > 0
+}
+
+// relay publishes a batch of events in id order until the deadline, it
+// reports whether the batch was full. Each event is marked as soon as the
+// sinks took it, a crash publishes at most that event twice.
+func relay(ctx context.Context, db *gorm.DB, s Settings, sinks []Sink, until time.Time) (bool, error) {
+	db = db.WithContext(ctx)
+
+	// the aggregates waiting for a retry are left out, their later events
+	// would be held back anyway
+	waiting := db.Model(&models.OutboxEvent{}).
+		Select("aggregate_type, aggregate_id").
+		Where("published_at IS NULL AND next_attempt_at > ?", time.Now())
+	var pending []models.OutboxEvent
+	err := db.Where("published_at IS NULL").
+		Where("(aggregate_type, aggregate_id) NOT IN (?)", waiting).
+		Order("id").
+		Limit(s.BatchSize).
+		Find(&pending).Error
+	if err != nil {
+		return false, err
+	}
+
+	blocked := map[string]bool{}
+	for _, event := range pending {
+		if time.Now().After(until) || ctx.Err() != nil {
+			return true, nil // the next scan renews the lease first
+		}
+		key := aggregateKey(event)
+		if blocked[key] {
+			continue
+		}
+
+		done, err := publish(ctx, event, sinks)
+		updates := map[string]interface{}{"published_to": done, "last_error": ""}
+		if err != nil {
+			blocked[key] = true
+			updates["attempts"] = event.Attempts + 1
+			updates["next_attempt_at"] = time.Now().Add(backoff(s, event.Attempts+1))
+			updates["last_error"] = err.Error()
+		} else {
+			// the relay runs alone, so the sequence grows in commit order
+			updates["published_at"] = time.Now()
+			updates["published_seq"] = gorm.Expr("(SELECT COALESCE(MAX(published_seq), 0) + 1 FROM outbox_events)")
+		}
+		if err := db.Model(&event).Updates(updates).Error; err != nil {
+			return false, err
+		}
+		if _, ok := updates["published_at"]; ok {
+			signal(published)
+		}
+	}
+	return len(pending) == s.BatchSize, nil
+}
+
+// publish hands the event to the sinks that did not get it yet, it returns
+// the sinks done so far
+func publish(ctx context.Context, event models.OutboxEvent, sinks []Sink) (string, error) {
+	message := Message{
+		ID:         event.ID,
+		Aggregate:  aggregateKey(event),
+		Type:       event.EventType,
+		UserEmail:  event.UserEmail,
+		Payload:    json.RawMessage(event.Payload),
+		OccurredAt: event.OccurredAt,
+	}
+	done := event.PublishedTo
+	for _, sink := range sinks {
+		if publishedTo(done, sink.Name()) {
+			continue
+		}
+		if err := sink.Publish(ctx, message); err != nil {
+			return done, fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+		if done != "" {
+			done += ","
+		}
+		done += sink.Name()
+	}
+	return done, nil
+}
+
+func publishedTo(done, name string) bool {
+	for _, sink := range strings.Split(done, ",") {
+		if sink == name {
+			return true
+		}
+	}
+	return false
+}
+
+func aggregateKey(event models.OutboxEvent) string {
+	return fmt.Sprintf("%s:%d", event.AggregateType, event.AggregateID)
+}
+
+// newHolder names this instance in the lease
+func newHolder() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	host, _ := os.Hostname()
+	return host + "-" + hex.EncodeToString(buf)
+}
+
+// backoff is the delay before the next attempt, doubling from Backoff up to
+// MaxBackoff
+func backoff(s Settings, attempts int) time.Duration {
+	delay := s.Backoff
+	for i := 1; i < attempts && delay < s.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.MaxBackoff {
+		delay = s.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,189 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-api/internal/events"
+)
+
+// Message is an outbox event as handed to the sinks. Delivery is at least
+// once, consumers drop the ids they already handled.
+type Message struct {
+	ID         uint            `json:"id"`
+	Aggregate  string          `json:"aggregate"` // "<type>:<id>", the ordering key
+	Type       string          `json:"type"`
+	UserEmail  string          `json:"userEmail"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurredAt"`
+}
+
+// Sink publishes the messages somewhere, an error makes the relay retry the
+// message and hold back the later ones of its aggregate
+type Sink interface {
+	Name() string // unique, records which sinks got a message
+	Publish(ctx context.Context, m Message) error
+}
+
+// Bus publishes the messages to an in-process subscription of the events
+// package (feed, search, webhooks, achievements). Each subscription is its
+// own sink, so a failing one is retried without the others seeing the
+// event again.
+type Bus struct {
+	Subscription events.Subscription
+}
+
+func (b Bus) Name() string { return "bus:" + b.Subscription.Name }
+
+func (b Bus) Publish(ctx context.Context, m Message) error {
+	if !b.Subscription.Wants(m.Type) {
+		return nil
+	}
+	return b.Subscription.Handle(events.Event{Type: m.Type, UserEmail: m.UserEmail, Payload: m.Payload, OccurredAt: m.OccurredAt})
+}
+
+// Buses returns a Bus per shared subscription, for the relay, or per local
+// subscription, for Follow. The subscriptions are read once, so call it
+// after the subscribers have registered
+func Buses(local bool) []Sink {
+	var sinks []Sink
+	for _, s := range events.Subscriptions() {
+		if s.Local == local {
+			sinks = append(sinks, Bus{Subscription: s})
+		}
+	}
+	return sinks
+}
+
+// NATSPublisher is the part of a NATS connection the sink needs, a
+// *nats.Conn satisfies it
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSSink publishes every message as JSON on Prefix + its type, e.g.
+// "pokeapi.pokemon.created"
+type NATSSink struct {
+	Conn   NATSPublisher
+	Prefix string
+}
+
+func (s NATSSink) Name() string { return "nats" }
+
+func (s NATSSink) Publish(ctx context.Context, m Message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.Conn.Publish(s.Prefix+m.Type, data)
+}
+
+// KafkaMessage is a record written to a Kafka-compatible broker
+type KafkaMessage struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// KafkaWriter writes records to a Kafka-compatible broker, adapt the client
+// in use (kafka-go, franz-go, sarama...) to it
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, messages ...KafkaMessage) error
+}
+
+// KafkaSink writes every message as JSON to Topic, keyed by its aggregate
+// so the events of an aggregate stay in one partition
+type KafkaSink struct {
+	Writer KafkaWriter
+	Topic  string
+}
+
+func (s KafkaSink) Name() string { return "kafka" }
+
+func (s KafkaSink) Publish(ctx context.Context, m Message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.Writer.WriteMessages(ctx, KafkaMessage{
+		Topic: s.Topic,
+		Key:   []byte(m.Aggregate),
+		Value: data,
+		Headers: map[string]string{
+			"event-type": m.Type,
+			"event-id":   strconv.FormatUint(uint64(m.ID), 10),
+		},
+	})
+}
+
+// NATSMessage is a message received by MemoryNATS
+type NATSMessage struct {
+	Subject string
+	Data    []byte
+}
+
+// MemoryNATS is an in-memory NATSPublisher for tests and local runs
+type MemoryNATS struct {
+	mu       sync.Mutex
+	err      error
+	messages []NATSMessage
+}
+
+func (n *MemoryNATS) Publish(subject string, data []byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.messages = append(n.messages, NATSMessage{Subject: subject, Data: data})
+	return nil
+}
+
+// Messages returns the messages published so far
+func (n *MemoryNATS) Messages() []NATSMessage {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]NATSMessage(nil), n.messages...)
+}
+
+// Fail makes the publications fail with err, nil heals them
+func (n *MemoryNATS) Fail(err error) {
+	n.mu.Lock()
+	n.err = err
+	n.mu.Unlock()
+}
+
+// MemoryKafka is an in-memory KafkaWriter for tests and local runs
+type MemoryKafka struct {
+	mu       sync.Mutex
+	err      error
+	messages []KafkaMessage
+}
+
+func (k *MemoryKafka) WriteMessages(ctx context.Context, messages ...KafkaMessage) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.err != nil {
+		return k.err
+	}
+	k.messages = append(k.messages, messages...)
+	return nil
+}
+
+// Messages returns the records written so far
+func (k *MemoryKafka) Messages() []KafkaMessage {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]KafkaMessage(nil), k.messages...)
+}
+
+// Fail makes the writes fail with err, nil heals them
+func (k *MemoryKafka) Fail(err error) {
+	k.mu.Lock()
+	k.err = err
+	k.mu.Unlock()
+}
@@ -0,0 +1,131 @@
+package recommend
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"go-api/config"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Settings tune the similarity job
+type Settings struct {
+	Interval    time.Duration // between two computations
+	Neighbors   int           // neighbors kept per species
+	MinTogether int64         // trainers two species need in common to be neighbors
+}
+
+// LoadSettings reads the settings from the environment
+func LoadSettings() Settings {
+	return Settings{
+		Interval:    config.GetEnvDuration("RECOMMENDATIONS_INTERVAL", time.Hour), // Default hourly
+		Neighbors:   config.GetEnvInt("RECOMMENDATIONS_NEIGHBORS", 20),            // Default 20 per species
+		MinTogether: int64(config.GetEnvInt("RECOMMENDATIONS_MIN_TOGETHER", 2)),   // Default 2 trainers
+	}
+}
+
+// Start computes the neighbors now and then every Interval until ctx is
+// done. The computation replaces the whole table, so instances running it
+// at once only repeat each other.
+func Start(ctx context.Context, db *gorm.DB, s Settings) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := Compute(ctx, db, s); err != nil {
+			log.Printf("recommend: failed to compute the neighbors: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compute scores every pair of species favorited by the same trainers and
+// stores the closest neighbors of each species, it returns how many were
+// stored. Two species score the cosine of their trainers:
+// together / sqrt(trainers of a * trainers of b).
+func Compute(ctx context.Context, db *gorm.DB, s Settings) (int, error) {
+	db = db.WithContext(ctx)
+
+	var rows []trainerSpecies
+	if err := favoritedSpecies(db).Select("DISTINCT pokemons.user_email, species.id AS species_id").Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+	byTrainer := map[string][]uint{}
+	trainers := map[uint]int64{}
+	for _, row := range rows {
+		byTrainer[row.UserEmail] = append(byTrainer[row.UserEmail], row.SpeciesID)
+		trainers[row.SpeciesID]++
+	}
+
+	// count the trainers of each pair, both ways
+	together := map[uint]map[uint]int64{}
+	for _, owned := range byTrainer {
+		for _, a := range owned {
+			if together[a] == nil {
+				together[a] = map[uint]int64{}
+			}
+			for _, b := range owned {
+				if a != b {
+					together[a][b]++
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	var neighbors []models.SpeciesNeighbor
+	for a, counts := range together {
+		var closest []models.SpeciesNeighbor
+		for b, count := range counts {
+			if count < s.MinTogether {
+				continue
+			}
+			closest = append(closest, models.SpeciesNeighbor{
+				SpeciesID:  a,
+				NeighborID: b,
+				Score:      math.Round(float64(count)/math.Sqrt(float64(trainers[a]*trainers[b]))*10000) / 10000,
+				Together:   count,
+				ComputedAt: now,
+			})
+		}
+		sort.Slice(closest, func(i, j int) bool {
+			if closest[i].Score != closest[j].Score {
+				return closest[i].Score > closest[j].Score
+			}
+			if closest[i].Together != closest[j].Together {
+				return closest[i].Together > closest[j].Together
+			}
+			return closest[i].NeighborID < closest[j].NeighborID
+		})
+		if len(closest) > s.Neighbors {
+			closest = closest[:s.Neighbors]
+		}
+		for i := range closest {
+			closest[i].Rank = i + 1
+		}
+		neighbors = append(neighbors, closest...)
+	}
+
+	// replace the previous neighbors at once, readers see either set
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.SpeciesNeighbor{}).Error; err != nil {
+			return err
+		}
+		if len(neighbors) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(neighbors, 500).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(neighbors), nil
+}
@@ -0,0 +1,179 @@
+// Package recommend suggests species to a trainer from the species other
+// trainers favorited along theirs ("trainers who liked X also liked Y"). A
+// background job computes the neighbors of each species, trainers without
+// neighbors to go by get the popular species of their types.
+package recommend
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Reasons of a recommendation
+const (
+	ReasonSimilar = "similar" // favorited along the trainer's species
+	ReasonType    = "type"    // popular among the trainer's types
+	ReasonPopular = "popular" // popular among every trainer
+)
+
+// Recommendation is a species the trainer does not have yet
+type Recommendation struct {
+	Species  models.Species `json:"species"`
+	Score    float64        `json:"score"` // summed similarity to the trainer's species, 0 for the fallbacks
+	Reason   string         `json:"reason"`
+	Because  []string       `json:"because,omitempty"` // the trainer's species, or types, it comes from
+	Trainers int64          `json:"trainers"`          // trainers who favorited it
+}
+
+// trainerSpecies is a species favorited by a trainer
+type trainerSpecies struct {
+	UserEmail string
+	SpeciesID uint
+}
+
+// favoritedSpecies joins the favorites to their species
+func favoritedSpecies(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.Pokemon{}).Joins("JOIN species ON species.name = LOWER(pokemons.name)")
+}
+
+// Recommend returns up to limit species for the trainer, the neighbors of
+// their species first, then the popular species of their types or of the
+// given ones, then the popular species.
+func Recommend(ctx context.Context, db *gorm.DB, email string, types []string, limit int) ([]Recommendation, error) {
+	db = db.WithContext(ctx)
+
+	var owned []uint
+	err := favoritedSpecies(db).Where("pokemons.user_email = ?", email).Select("DISTINCT species.id").Scan(&owned).Error
+	if err != nil {
+		return nil, err
+	}
+	var catalog []models.Species
+	if err := db.Order("id").Find(&catalog).Error; err != nil {
+		return nil, err
+	}
+	byID := map[uint]models.Species{}
+	for _, s := range catalog {
+		byID[s.ID] = s
+	}
+	var popularity []struct {
+		SpeciesID uint
+		Trainers  int64
+	}
+	err = favoritedSpecies(db).
+		Select("species.id AS species_id, COUNT(DISTINCT pokemons.user_email) AS trainers").
+		Group("species.id").
+		Scan(&popularity).Error
+	if err != nil {
+		return nil, err
+	}
+	trainers := map[uint]int64{}
+	for _, p := range popularity {
+		trainers[p.SpeciesID] = p.Trainers
+	}
+
+	// the trainer's species are never recommended
+	taken := map[uint]bool{}
+	for _, id := range owned {
+		taken[id] = true
+	}
+
+	// sum the similarity of the neighbors over the trainer's species
+	recommendations := []Recommendation{}
+	if len(owned) > 0 {
+		var neighbors []models.SpeciesNeighbor
+		err := db.Where("species_id IN ? AND neighbor_id NOT IN ?", owned, owned).
+			Order("species_id, rank").
+			Find(&neighbors).Error
+		if err != nil {
+			return nil, err
+		}
+		index := map[uint]int{}
+		for _, n := range neighbors {
+			species, ok := byID[n.NeighborID]
+			if !ok {
+				continue
+			}
+			i, seen := index[n.NeighborID]
+			if !seen {
+				i = len(recommendations)
+				index[n.NeighborID] = i
+				recommendations = append(recommendations, Recommendation{Species: species, Reason: ReasonSimilar, Trainers: trainers[n.NeighborID]})
+			}
+			recommendations[i].Score = math.Round((recommendations[i].Score+n.Score)*10000) / 10000
+			recommendations[i].Because = append(recommendations[i].Because, byID[n.SpeciesID].Name)
+		}
+		sort.SliceStable(recommendations, func(i, j int) bool {
+			if recommendations[i].Score != recommendations[j].Score {
+				return recommendations[i].Score > recommendations[j].Score
+			}
+			return recommendations[i].Species.ID < recommendations[j].Species.ID
+		})
+		if len(recommendations) > limit {
+			recommendations = recommendations[:limit]
+		}
+		for i := range recommendations {
+			taken[recommendations[i].Species.ID] = true
+		}
+	}
+	if len(recommendations) >= limit {
+		return recommendations, nil
+	}
+
+	// cold start: fill with the most favorited species, those of the
+	// trainer's types first
+	wanted := map[string]bool{}
+	for _, t := range types {
+		wanted[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	for _, id := range owned {
+		for _, t := range speciesTypes(byID[id]) {
+			wanted[t] = true
+		}
+	}
+	sort.SliceStable(catalog, func(i, j int) bool {
+		return trainers[catalog[i].ID] > trainers[catalog[j].ID]
+	})
+	for _, reason := range []string{ReasonType, ReasonPopular} {
+		for _, species := range catalog {
+			if len(recommendations) >= limit {
+				return recommendations, nil
+			}
+			if taken[species.ID] {
+				continue
+			}
+			var matched []string
+			for _, t := range speciesTypes(species) {
+				if wanted[t] {
+					matched = append(matched, t)
+				}
+			}
+			if reason == ReasonType && len(matched) == 0 {
+				continue
+			}
+			taken[species.ID] = true
+			recommendation := Recommendation{Species: species, Reason: reason, Trainers: trainers[species.ID]}
+			if reason == ReasonType {
+				recommendation.Because = matched
+			}
+			recommendations = append(recommendations, recommendation)
+		}
+	}
+	return recommendations, nil
+}
+
+// speciesTypes reads "grass/poison"
+func speciesTypes(s models.Species) []string {
+	var types []string
+	for _, t := range strings.Split(strings.ToLower(s.Types), "/") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
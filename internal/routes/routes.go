@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"go-api/config"
 	"go-api/internal/handlers"
 	"go-api/internal/middleware"
+	"time"
 
 	_ "go-api/docs" // important: for loading docs generated by swag
 
@@ -21,17 +23,33 @@ func SetupRoutes() *gin.Engine {
 	// Set up swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Apply logger middleware
+	// Apply logger middleware, with a correlation id per request
+	r.Use(middleware.RequestIDMiddleware())
 	r.Use(middleware.LoggerMiddleware())
 
 	// Public route (Login, Register)
 	r.POST("/api/v1/login", handlers.LoginHandler)
 	r.POST("/api/v1/register", handlers.RegisterHandler)
 
+	// Signed file URLs
+	r.GET("/api/v1/files/*key", handlers.ServeFile)
+
+	// Sprite proxy (public so it works in image tags)
+	r.GET("/api/v1/sprites/proxy", handlers.ProxySprite)
+
+	// Feed routes, EventSource and browser sockets pass the token in the query
+	stream := r.Group("/api/v1")
+	stream.Use(middleware.QueryTokenMiddleware(), middleware.AuthMiddleware())
+	stream.GET("/feed", handlers.StreamFeed)
+	stream.GET("/feed/ws", handlers.SocketFeed)
+
 	// Protected routes
 	protected := r.Group("/api/v1")
 	protected.Use(middleware.AuthMiddleware())
 
+	// Create routes replay their response on retries with the same Idempotency-Key
+	idempotent := idempotency()
+
 	// Admin routes (only admin can modify data)
 	admin := protected.Group("/")
 	admin.Use(middleware.RoleMiddleware("admin"))
@@ -40,19 +58,141 @@ func SetupRoutes() *gin.Engine {
 	manager := protected.Group("/")
 	manager.Use(middleware.RoleMiddleware("manager"))
 
+	// User routes (deprecated, see /api/v2/users)
+	deprecatedUsers := deprecation("/api/v2/users")
+	admin.GET("/users", deprecatedUsers, handlers.GetUsers)
+	admin.GET("/user", deprecatedUsers, handlers.GetUserByID)
+	admin.POST("/user/create", deprecatedUsers, idempotent, handlers.CreateUser)
+	admin.PUT("/user/update", deprecatedUsers, handlers.UpdateUser)
+	admin.PATCH("/user/update", deprecatedUsers, handlers.PatchUser)
+	admin.DELETE("/user/delete", deprecatedUsers, handlers.DeleteUser)
+
+	// Pokemon routes (deprecated, see /api/v2/pokemons)
+	deprecatedPokemons := deprecation("/api/v2/pokemons")
+	protected.GET("/pokemons", deprecatedPokemons, handlers.GetPokemons)
+	protected.GET("/pokemon", deprecatedPokemons, handlers.GetPokemonByID)
+	protected.POST("/pokemon/create", deprecatedPokemons, idempotent, handlers.CreatePokemon)
+	protected.PUT("/pokemon/update", deprecatedPokemons, handlers.UpdatePokemon)
+	protected.PATCH("/pokemon/update", deprecatedPokemons, handlers.PatchPokemon)
+	protected.DELETE("/pokemon/delete", deprecatedPokemons, handlers.DeletePokemon)
+	protected.PUT("/pokemon/tags", handlers.SetPokemonTags)
+	protected.GET("/pokemon/sprite", handlers.GetPokemonSprite)
+	protected.POST("/pokemon/sprite", handlers.UploadPokemonSprite)
+	manager.POST("/pokemon/import", idempotent, handlers.ImportPokemons)
+
+	// Species routes
+	protected.GET("/species", handlers.GetSpecies)
+	protected.GET("/me/completion", handlers.GetMyCompletion)
+
+	// Search routes
+	protected.GET("/search", handlers.Search)
+
+	// Tag routes
+	protected.GET("/tags", handlers.GetTags)
+
+	// Box routes
+	protected.GET("/boxes", handlers.GetBoxes)
+	protected.GET("/box", handlers.GetBoxByID)
+	protected.POST("/box/create", idempotent, handlers.CreateBox)
+	protected.PUT("/box/update", handlers.UpdateBox)
+	protected.DELETE("/box/delete", handlers.DeleteBox)
+	protected.POST("/box/move", handlers.MoveBoxPokemons)
+	protected.POST("/box/copy", handlers.CopyBoxPokemons)
+
+	// Achievement routes
+	protected.GET("/me/achievements", handlers.GetMyAchievements)
+	admin.POST("/achievements/backfill", handlers.BackfillAchievements)
+
+	// Recommendation routes
+	protected.GET("/me/recommendations", handlers.GetMyRecommendations)
+	admin.POST("/recommendations/refresh", handlers.RefreshRecommendations)
+
+	// Analytics routes
+	manager.GET("/analytics/top-species", handlers.GetTopSpecies)
+	manager.GET("/analytics/types", handlers.GetTypeDistribution)
+	manager.GET("/analytics/favorites", handlers.GetFavoritesOverTime)
+	manager.GET("/analytics/active-users", handlers.GetActiveUsers)
+	manager.POST("/analytics/refresh", handlers.RefreshAnalytics)
+
+	// Usage routes
+	admin.GET("/usage/endpoints", handlers.GetEndpointUsage)
+	admin.GET("/usage/clients", handlers.GetTopClients)
+	admin.POST("/usage/refresh", handlers.RefreshUsage)
+
+	// Log routes
+	admin.GET("/logs", handlers.GetLogs)
+	admin.GET("/log", handlers.GetLog)
+
+	// GraphQL route, role rules are checked per field
+	protected.POST("/graphql", handlers.GraphQL)
+
+	// Batch route, sub-requests are served by this router
+	protected.POST("/batch", handlers.Batch(r))
+
+	// Export routes
+	admin.GET("/export/pokemons", handlers.ExportPokemons)
+	admin.GET("/export/users", handlers.ExportUsers)
+	admin.GET("/export/job", handlers.GetExportJob)
+
+	// Webhook routes
+	admin.GET("/webhooks", handlers.GetWebhooks)
+	admin.GET("/webhook", handlers.GetWebhookByID)
+	admin.POST("/webhook/create", handlers.CreateWebhook)
+	admin.PUT("/webhook/update", handlers.UpdateWebhook)
+	admin.DELETE("/webhook/delete", handlers.DeleteWebhook)
+	admin.POST("/webhook/ping", handlers.PingWebhook)
+	admin.GET("/webhook/deliveries", handlers.GetWebhookDeliveries)
+	admin.GET("/webhook/delivery", handlers.GetWebhookDelivery)
+	admin.POST("/webhook/delivery/replay", handlers.ReplayWebhookDelivery)
+
+	// v2 routes use resource paths and HTTP semantics
+	v2 := r.Group("/api/v2")
+	v2.Use(middleware.APIVersionMiddleware(2))
+	v2.Use(middleware.AuthMiddleware())
+
+	v2Admin := v2.Group("/")
+	v2Admin.Use(middleware.RoleMiddleware("admin"))
+
+	v2Manager := v2.Group("/")
+	v2Manager.Use(middleware.RoleMiddleware("manager"))
+
 	// User routes
-	admin.GET("/users", handlers.GetUsers)
-	admin.GET("/user", handlers.GetUserByID)
-	admin.POST("/user/create", handlers.CreateUser)
-	admin.PUT("/user/update", handlers.UpdateUser)
-	admin.DELETE("/user/delete", handlers.DeleteUser)
-	
+	v2Admin.GET("/users", handlers.GetUsers)
+	v2Admin.POST("/users", idempotent, handlers.CreateUser)
+	v2Admin.GET("/users/:id", handlers.GetUserByID)
+	v2Admin.PUT("/users/:id", handlers.UpdateUser)
+	v2Admin.PATCH("/users/:id", handlers.PatchUser)
+	v2Admin.DELETE("/users/:id", handlers.DeleteUser)
+
 	// Pokemon routes
-	protected.GET("/pokemons", handlers.GetPokemons)
-	protected.GET("/pokemon", handlers.GetPokemonByID)
-	protected.POST("/pokemon/create", handlers.CreatePokemon)
-	protected.PUT("/pokemon/update", handlers.UpdatePokemon)
-	protected.DELETE("/pokemon/delete", handlers.DeletePokemon)
+	v2.GET("/pokemons", handlers.GetPokemons)
+	v2.POST("/pokemons", idempotent, handlers.CreatePokemon)
+	v2.GET("/pokemons/:id", handlers.GetPokemonByID)
+	v2.PUT("/pokemons/:id", handlers.UpdatePokemon)
+	v2.PATCH("/pokemons/:id", handlers.PatchPokemon)
+	v2.DELETE("/pokemons/:id", handlers.DeletePokemon)
+	v2Manager.POST("/pokemons/import", idempotent, handlers.ImportPokemons)
 
 	return r
 }
+
+// idempotency stores the responses of keyed requests for IDEMPOTENCY_TTL
+// (e.g. 24h)
+func idempotency() gin.HandlerFunc {
+	ttl, err := time.ParseDuration(config.GetEnv("IDEMPOTENCY_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = 24 * time.Hour // Default TTL
+	}
+	return middleware.IdempotencyMiddleware(ttl)
+}
+
+// deprecation marks a v1 route as deprecated, the sunset date comes from
+// API_V1_SUNSET (YYYY-MM-DD)
+func deprecation(successor string) gin.HandlerFunc {
+	deprecatedAt := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	sunset, err := time.Parse("2006-01-02", config.GetEnv("API_V1_SUNSET"))
+	if err != nil {
+		sunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC) // Default sunset
+	}
+	return middleware.DeprecationMiddleware(deprecatedAt, sunset, successor)
+}
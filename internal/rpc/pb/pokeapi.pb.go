@@ -0,0 +1,1246 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pokeapi/v1/pokeapi.proto
+
+// Typed access to users and favorites for internal services. Calls carry the
+// JWT of the REST API in the "authorization" metadata as "Bearer <token>".
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Role          string                 `protobuf:"bytes,4,opt,name=role,proto3" json:"role,omitempty"`
+	Version       uint32                 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *User) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *User) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetUserRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Lookup:
+	//
+	//	*GetUserRequest_Id
+	//	*GetUserRequest_Email
+	Lookup        isGetUserRequest_Lookup `protobuf_oneof:"lookup"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetUserRequest) GetLookup() isGetUserRequest_Lookup {
+	if x != nil {
+		return x.Lookup
+	}
+	return nil
+}
+
+func (x *GetUserRequest) GetId() uint64 {
+	if x != nil {
+		if x, ok := x.Lookup.(*GetUserRequest_Id); ok {
+			return x.Id
+		}
+	}
+	return 0
+}
+
+func (x *GetUserRequest) GetEmail() string {
+	if x != nil {
+		if x, ok := x.Lookup.(*GetUserRequest_Email); ok {
+			return x.Email
+		}
+	}
+	return ""
+}
+
+type isGetUserRequest_Lookup interface {
+	isGetUserRequest_Lookup()
+}
+
+type GetUserRequest_Id struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3,oneof"`
+}
+
+type GetUserRequest_Email struct {
+	Email string `protobuf:"bytes,2,opt,name=email,proto3,oneof"`
+}
+
+func (*GetUserRequest_Id) isGetUserRequest_Lookup() {}
+
+func (*GetUserRequest_Email) isGetUserRequest_Lookup() {}
+
+type Tag struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tag) Reset() {
+	*x = Tag{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tag) ProtoMessage() {}
+
+func (x *Tag) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tag.ProtoReflect.Descriptor instead.
+func (*Tag) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Tag) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Tag) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Favorite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserEmail     string                 `protobuf:"bytes,2,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Notes         string                 `protobuf:"bytes,5,opt,name=notes,proto3" json:"notes,omitempty"`
+	Sprite        string                 `protobuf:"bytes,6,opt,name=sprite,proto3" json:"sprite,omitempty"`
+	Shiny         bool                   `protobuf:"varint,7,opt,name=shiny,proto3" json:"shiny,omitempty"`
+	Form          string                 `protobuf:"bytes,8,opt,name=form,proto3" json:"form,omitempty"`
+	Gender        string                 `protobuf:"bytes,9,opt,name=gender,proto3" json:"gender,omitempty"`
+	Version       uint32                 `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	Tags          []*Tag                 `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Favorite) Reset() {
+	*x = Favorite{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Favorite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Favorite) ProtoMessage() {}
+
+func (x *Favorite) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Favorite.ProtoReflect.Descriptor instead.
+func (*Favorite) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Favorite) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Favorite) GetUserEmail() string {
+	if x != nil {
+		return x.UserEmail
+	}
+	return ""
+}
+
+func (x *Favorite) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Favorite) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Favorite) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *Favorite) GetSprite() string {
+	if x != nil {
+		return x.Sprite
+	}
+	return ""
+}
+
+func (x *Favorite) GetShiny() bool {
+	if x != nil {
+		return x.Shiny
+	}
+	return false
+}
+
+func (x *Favorite) GetForm() string {
+	if x != nil {
+		return x.Form
+	}
+	return ""
+}
+
+func (x *Favorite) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+func (x *Favorite) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Favorite) GetTags() []*Tag {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Favorite) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Favorite) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetFavoriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFavoriteRequest) Reset() {
+	*x = GetFavoriteRequest{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFavoriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFavoriteRequest) ProtoMessage() {}
+
+func (x *GetFavoriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFavoriteRequest.ProtoReflect.Descriptor instead.
+func (*GetFavoriteRequest) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetFavoriteRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// Filter is a field[op]=value condition, op defaults to eq
+type Filter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Op            string                 `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Filter) Reset() {
+	*x = Filter{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filter) ProtoMessage() {}
+
+func (x *Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filter.ProtoReflect.Descriptor instead.
+func (*Filter) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Filter) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *Filter) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *Filter) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// FilterGroup is an or[group] of conditions that must all match
+type FilterGroup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filters       []*Filter              `protobuf:"bytes,1,rep,name=filters,proto3" json:"filters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterGroup) Reset() {
+	*x = FilterGroup{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterGroup) ProtoMessage() {}
+
+func (x *FilterGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterGroup.ProtoReflect.Descriptor instead.
+func (*FilterGroup) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FilterGroup) GetFilters() []*Filter {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+type ListFavoritesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// conditions that must all match
+	Filters []*Filter `protobuf:"bytes,1,rep,name=filters,proto3" json:"filters,omitempty"`
+	// groups of which one must match
+	Or []*FilterGroup `protobuf:"bytes,2,rep,name=or,proto3" json:"or,omitempty"`
+	// tags that must all match
+	Tags   []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	BoxId  uint64   `protobuf:"varint,4,opt,name=box_id,json=boxId,proto3" json:"box_id,omitempty"`
+	Page   int32    `protobuf:"varint,5,opt,name=page,proto3" json:"page,omitempty"`
+	Limit  int32    `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	SortBy string   `protobuf:"bytes,7,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	// asc or desc
+	Order string `protobuf:"bytes,8,opt,name=order,proto3" json:"order,omitempty"`
+	// keyset pagination, from next_cursor or prev_cursor
+	Cursor string `protobuf:"bytes,9,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// start keyset pagination without a cursor
+	PaginateCursor bool  `protobuf:"varint,10,opt,name=paginate_cursor,json=paginateCursor,proto3" json:"paginate_cursor,omitempty"`
+	IncludeTotal   *bool `protobuf:"varint,11,opt,name=include_total,json=includeTotal,proto3,oneof" json:"include_total,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListFavoritesRequest) Reset() {
+	*x = ListFavoritesRequest{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFavoritesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFavoritesRequest) ProtoMessage() {}
+
+func (x *ListFavoritesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFavoritesRequest.ProtoReflect.Descriptor instead.
+func (*ListFavoritesRequest) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListFavoritesRequest) GetFilters() []*Filter {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *ListFavoritesRequest) GetOr() []*FilterGroup {
+	if x != nil {
+		return x.Or
+	}
+	return nil
+}
+
+func (x *ListFavoritesRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListFavoritesRequest) GetBoxId() uint64 {
+	if x != nil {
+		return x.BoxId
+	}
+	return 0
+}
+
+func (x *ListFavoritesRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListFavoritesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListFavoritesRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListFavoritesRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+func (x *ListFavoritesRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListFavoritesRequest) GetPaginateCursor() bool {
+	if x != nil {
+		return x.PaginateCursor
+	}
+	return false
+}
+
+func (x *ListFavoritesRequest) GetIncludeTotal() bool {
+	if x != nil && x.IncludeTotal != nil {
+		return *x.IncludeTotal
+	}
+	return false
+}
+
+type ListFavoritesResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Items           []*Favorite            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	CurrentPage     int32                  `protobuf:"varint,2,opt,name=current_page,json=currentPage,proto3" json:"current_page,omitempty"`
+	TotalPages      int32                  `protobuf:"varint,3,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	TotalItems      int64                  `protobuf:"varint,4,opt,name=total_items,json=totalItems,proto3" json:"total_items,omitempty"`
+	Limit           int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	HasNextPage     bool                   `protobuf:"varint,6,opt,name=has_next_page,json=hasNextPage,proto3" json:"has_next_page,omitempty"`
+	HasPreviousPage bool                   `protobuf:"varint,7,opt,name=has_previous_page,json=hasPreviousPage,proto3" json:"has_previous_page,omitempty"`
+	NextCursor      string                 `protobuf:"bytes,8,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	PrevCursor      string                 `protobuf:"bytes,9,opt,name=prev_cursor,json=prevCursor,proto3" json:"prev_cursor,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListFavoritesResponse) Reset() {
+	*x = ListFavoritesResponse{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFavoritesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFavoritesResponse) ProtoMessage() {}
+
+func (x *ListFavoritesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFavoritesResponse.ProtoReflect.Descriptor instead.
+func (*ListFavoritesResponse) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListFavoritesResponse) GetItems() []*Favorite {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ListFavoritesResponse) GetCurrentPage() int32 {
+	if x != nil {
+		return x.CurrentPage
+	}
+	return 0
+}
+
+func (x *ListFavoritesResponse) GetTotalPages() int32 {
+	if x != nil {
+		return x.TotalPages
+	}
+	return 0
+}
+
+func (x *ListFavoritesResponse) GetTotalItems() int64 {
+	if x != nil {
+		return x.TotalItems
+	}
+	return 0
+}
+
+func (x *ListFavoritesResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListFavoritesResponse) GetHasNextPage() bool {
+	if x != nil {
+		return x.HasNextPage
+	}
+	return false
+}
+
+func (x *ListFavoritesResponse) GetHasPreviousPage() bool {
+	if x != nil {
+		return x.HasPreviousPage
+	}
+	return false
+}
+
+func (x *ListFavoritesResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *ListFavoritesResponse) GetPrevCursor() string {
+	if x != nil {
+		return x.PrevCursor
+	}
+	return ""
+}
+
+type CreateFavoriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserEmail     string                 `protobuf:"bytes,1,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Notes         string                 `protobuf:"bytes,4,opt,name=notes,proto3" json:"notes,omitempty"`
+	Sprite        string                 `protobuf:"bytes,5,opt,name=sprite,proto3" json:"sprite,omitempty"`
+	Shiny         bool                   `protobuf:"varint,6,opt,name=shiny,proto3" json:"shiny,omitempty"`
+	Form          string                 `protobuf:"bytes,7,opt,name=form,proto3" json:"form,omitempty"`
+	Gender        string                 `protobuf:"bytes,8,opt,name=gender,proto3" json:"gender,omitempty"`
+	Tags          []string               `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateFavoriteRequest) Reset() {
+	*x = CreateFavoriteRequest{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFavoriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFavoriteRequest) ProtoMessage() {}
+
+func (x *CreateFavoriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFavoriteRequest.ProtoReflect.Descriptor instead.
+func (*CreateFavoriteRequest) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CreateFavoriteRequest) GetUserEmail() string {
+	if x != nil {
+		return x.UserEmail
+	}
+	return ""
+}
+
+func (x *CreateFavoriteRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateFavoriteRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CreateFavoriteRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *CreateFavoriteRequest) GetSprite() string {
+	if x != nil {
+		return x.Sprite
+	}
+	return ""
+}
+
+func (x *CreateFavoriteRequest) GetShiny() bool {
+	if x != nil {
+		return x.Shiny
+	}
+	return false
+}
+
+func (x *CreateFavoriteRequest) GetForm() string {
+	if x != nil {
+		return x.Form
+	}
+	return ""
+}
+
+func (x *CreateFavoriteRequest) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+func (x *CreateFavoriteRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+// UpdateFavoriteRequest changes the fields that are set, the others keep
+// their value
+type UpdateFavoriteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// expected version like If-Match, 0 accepts any
+	Version       uint32  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name          *string `protobuf:"bytes,3,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Type          *string `protobuf:"bytes,4,opt,name=type,proto3,oneof" json:"type,omitempty"`
+	Notes         *string `protobuf:"bytes,5,opt,name=notes,proto3,oneof" json:"notes,omitempty"`
+	Shiny         *bool   `protobuf:"varint,6,opt,name=shiny,proto3,oneof" json:"shiny,omitempty"`
+	Form          *string `protobuf:"bytes,7,opt,name=form,proto3,oneof" json:"form,omitempty"`
+	Gender        *string `protobuf:"bytes,8,opt,name=gender,proto3,oneof" json:"gender,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateFavoriteRequest) Reset() {
+	*x = UpdateFavoriteRequest{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateFavoriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateFavoriteRequest) ProtoMessage() {}
+
+func (x *UpdateFavoriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateFavoriteRequest.ProtoReflect.Descriptor instead.
+func (*UpdateFavoriteRequest) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdateFavoriteRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateFavoriteRequest) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateFavoriteRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateFavoriteRequest) GetType() string {
+	if x != nil && x.Type != nil {
+		return *x.Type
+	}
+	return ""
+}
+
+func (x *UpdateFavoriteRequest) GetNotes() string {
+	if x != nil && x.Notes != nil {
+		return *x.Notes
+	}
+	return ""
+}
+
+func (x *UpdateFavoriteRequest) GetShiny() bool {
+	if x != nil && x.Shiny != nil {
+		return *x.Shiny
+	}
+	return false
+}
+
+func (x *UpdateFavoriteRequest) GetForm() string {
+	if x != nil && x.Form != nil {
+		return *x.Form
+	}
+	return ""
+}
+
+func (x *UpdateFavoriteRequest) GetGender() string {
+	if x != nil && x.Gender != nil {
+		return *x.Gender
+	}
+	return ""
+}
+
+type DeleteFavoriteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// expected version like If-Match, 0 accepts any
+	Version       uint32 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFavoriteRequest) Reset() {
+	*x = DeleteFavoriteRequest{}
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFavoriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFavoriteRequest) ProtoMessage() {}
+
+func (x *DeleteFavoriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pokeapi_v1_pokeapi_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFavoriteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFavoriteRequest) Descriptor() ([]byte, []int) {
+	return file_pokeapi_v1_pokeapi_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteFavoriteRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteFavoriteRequest) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+var File_pokeapi_v1_pokeapi_proto protoreflect.FileDescriptor
+
+const file_pokeapi_v1_pokeapi_proto_rawDesc = "" +
+	"\n" +
+	"\x18pokeapi/v1/pokeapi.proto\x12\n" +
+	"pokeapi.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe4\x01\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x12\n" +
+	"\x04role\x18\x04 \x01(\tR\x04role\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\rR\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"D\n" +
+	"\x0eGetUserRequest\x12\x10\n" +
+	"\x02id\x18\x01 \x01(\x04H\x00R\x02id\x12\x16\n" +
+	"\x05email\x18\x02 \x01(\tH\x00R\x05emailB\b\n" +
+	"\x06lookup\")\n" +
+	"\x03Tag\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"\x86\x03\n" +
+	"\bFavorite\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
+	"\n" +
+	"user_email\x18\x02 \x01(\tR\tuserEmail\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x14\n" +
+	"\x05notes\x18\x05 \x01(\tR\x05notes\x12\x16\n" +
+	"\x06sprite\x18\x06 \x01(\tR\x06sprite\x12\x14\n" +
+	"\x05shiny\x18\a \x01(\bR\x05shiny\x12\x12\n" +
+	"\x04form\x18\b \x01(\tR\x04form\x12\x16\n" +
+	"\x06gender\x18\t \x01(\tR\x06gender\x12\x18\n" +
+	"\aversion\x18\n" +
+	" \x01(\rR\aversion\x12#\n" +
+	"\x04tags\x18\v \x03(\v2\x0f.pokeapi.v1.TagR\x04tags\x129\n" +
+	"\n" +
+	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"$\n" +
+	"\x12GetFavoriteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"D\n" +
+	"\x06Filter\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\";\n" +
+	"\vFilterGroup\x12,\n" +
+	"\afilters\x18\x01 \x03(\v2\x12.pokeapi.v1.FilterR\afilters\"\xee\x02\n" +
+	"\x14ListFavoritesRequest\x12,\n" +
+	"\afilters\x18\x01 \x03(\v2\x12.pokeapi.v1.FilterR\afilters\x12'\n" +
+	"\x02or\x18\x02 \x03(\v2\x17.pokeapi.v1.FilterGroupR\x02or\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12\x15\n" +
+	"\x06box_id\x18\x04 \x01(\x04R\x05boxId\x12\x12\n" +
+	"\x04page\x18\x05 \x01(\x05R\x04page\x12\x14\n" +
+	"\x05limit\x18\x06 \x01(\x05R\x05limit\x12\x17\n" +
+	"\asort_by\x18\a \x01(\tR\x06sortBy\x12\x14\n" +
+	"\x05order\x18\b \x01(\tR\x05order\x12\x16\n" +
+	"\x06cursor\x18\t \x01(\tR\x06cursor\x12'\n" +
+	"\x0fpaginate_cursor\x18\n" +
+	" \x01(\bR\x0epaginateCursor\x12(\n" +
+	"\rinclude_total\x18\v \x01(\bH\x00R\fincludeTotal\x88\x01\x01B\x10\n" +
+	"\x0e_include_total\"\xd0\x02\n" +
+	"\x15ListFavoritesResponse\x12*\n" +
+	"\x05items\x18\x01 \x03(\v2\x14.pokeapi.v1.FavoriteR\x05items\x12!\n" +
+	"\fcurrent_page\x18\x02 \x01(\x05R\vcurrentPage\x12\x1f\n" +
+	"\vtotal_pages\x18\x03 \x01(\x05R\n" +
+	"totalPages\x12\x1f\n" +
+	"\vtotal_items\x18\x04 \x01(\x03R\n" +
+	"totalItems\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\"\n" +
+	"\rhas_next_page\x18\x06 \x01(\bR\vhasNextPage\x12*\n" +
+	"\x11has_previous_page\x18\a \x01(\bR\x0fhasPreviousPage\x12\x1f\n" +
+	"\vnext_cursor\x18\b \x01(\tR\n" +
+	"nextCursor\x12\x1f\n" +
+	"\vprev_cursor\x18\t \x01(\tR\n" +
+	"prevCursor\"\xe2\x01\n" +
+	"\x15CreateFavoriteRequest\x12\x1d\n" +
+	"\n" +
+	"user_email\x18\x01 \x01(\tR\tuserEmail\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x14\n" +
+	"\x05notes\x18\x04 \x01(\tR\x05notes\x12\x16\n" +
+	"\x06sprite\x18\x05 \x01(\tR\x06sprite\x12\x14\n" +
+	"\x05shiny\x18\x06 \x01(\bR\x05shiny\x12\x12\n" +
+	"\x04form\x18\a \x01(\tR\x04form\x12\x16\n" +
+	"\x06gender\x18\b \x01(\tR\x06gender\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\"\x99\x02\n" +
+	"\x15UpdateFavoriteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\rR\aversion\x12\x17\n" +
+	"\x04name\x18\x03 \x01(\tH\x00R\x04name\x88\x01\x01\x12\x17\n" +
+	"\x04type\x18\x04 \x01(\tH\x01R\x04type\x88\x01\x01\x12\x19\n" +
+	"\x05notes\x18\x05 \x01(\tH\x02R\x05notes\x88\x01\x01\x12\x19\n" +
+	"\x05shiny\x18\x06 \x01(\bH\x03R\x05shiny\x88\x01\x01\x12\x17\n" +
+	"\x04form\x18\a \x01(\tH\x04R\x04form\x88\x01\x01\x12\x1b\n" +
+	"\x06gender\x18\b \x01(\tH\x05R\x06gender\x88\x01\x01B\a\n" +
+	"\x05_nameB\a\n" +
+	"\x05_typeB\b\n" +
+	"\x06_notesB\b\n" +
+	"\x06_shinyB\a\n" +
+	"\x05_formB\t\n" +
+	"\a_gender\"A\n" +
+	"\x15DeleteFavoriteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\rR\aversion2F\n" +
+	"\vUserService\x127\n" +
+	"\aGetUser\x12\x1a.pokeapi.v1.GetUserRequest\x1a\x10.pokeapi.v1.User2\x8d\x03\n" +
+	"\x0fFavoriteService\x12C\n" +
+	"\vGetFavorite\x12\x1e.pokeapi.v1.GetFavoriteRequest\x1a\x14.pokeapi.v1.Favorite\x12T\n" +
+	"\rListFavorites\x12 .pokeapi.v1.ListFavoritesRequest\x1a!.pokeapi.v1.ListFavoritesResponse\x12I\n" +
+	"\x0eCreateFavorite\x12!.pokeapi.v1.CreateFavoriteRequest\x1a\x14.pokeapi.v1.Favorite\x12I\n" +
+	"\x0eUpdateFavorite\x12!.pokeapi.v1.UpdateFavoriteRequest\x1a\x14.pokeapi.v1.Favorite\x12I\n" +
+	"\x0eDeleteFavorite\x12!.pokeapi.v1.DeleteFavoriteRequest\x1a\x14.pokeapi.v1.FavoriteB\x1bZ\x19go-api/internal/rpc/pb;pbb\x06proto3"
+
+var (
+	file_pokeapi_v1_pokeapi_proto_rawDescOnce sync.Once
+	file_pokeapi_v1_pokeapi_proto_rawDescData []byte
+)
+
+func file_pokeapi_v1_pokeapi_proto_rawDescGZIP() []byte {
+	file_pokeapi_v1_pokeapi_proto_rawDescOnce.Do(func() {
+		file_pokeapi_v1_pokeapi_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pokeapi_v1_pokeapi_proto_rawDesc), len(file_pokeapi_v1_pokeapi_proto_rawDesc)))
+	})
+	return file_pokeapi_v1_pokeapi_proto_rawDescData
+}
+
+var file_pokeapi_v1_pokeapi_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_pokeapi_v1_pokeapi_proto_goTypes = []any{
+	(*User)(nil),                  // 0: pokeapi.v1.User
+	(*GetUserRequest)(nil),        // 1: pokeapi.v1.GetUserRequest
+	(*Tag)(nil),                   // 2: pokeapi.v1.Tag
+	(*Favorite)(nil),              // 3: pokeapi.v1.Favorite
+	(*GetFavoriteRequest)(nil),    // 4: pokeapi.v1.GetFavoriteRequest
+	(*Filter)(nil),                // 5: pokeapi.v1.Filter
+	(*FilterGroup)(nil),           // 6: pokeapi.v1.FilterGroup
+	(*ListFavoritesRequest)(nil),  // 7: pokeapi.v1.ListFavoritesRequest
+	(*ListFavoritesResponse)(nil), // 8: pokeapi.v1.ListFavoritesResponse
+	(*CreateFavoriteRequest)(nil), // 9: pokeapi.v1.CreateFavoriteRequest
+	(*UpdateFavoriteRequest)(nil), // 10: pokeapi.v1.UpdateFavoriteRequest
+	(*DeleteFavoriteRequest)(nil), // 11: pokeapi.v1.DeleteFavoriteRequest
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+}
+var file_pokeapi_v1_pokeapi_proto_depIdxs = []int32{
+	12, // 0: pokeapi.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	12, // 1: pokeapi.v1.User.updated_at:type_name -> google.protobuf.Timestamp
+	2,  // 2: pokeapi.v1.Favorite.tags:type_name -> pokeapi.v1.Tag
+	12, // 3: pokeapi.v1.Favorite.created_at:type_name -> google.protobuf.Timestamp
+	12, // 4: pokeapi.v1.Favorite.updated_at:type_name -> google.protobuf.Timestamp
+	5,  // 5: pokeapi.v1.FilterGroup.filters:type_name -> pokeapi.v1.Filter
+	5,  // 6: pokeapi.v1.ListFavoritesRequest.filters:type_name -> pokeapi.v1.Filter
+	6,  // 7: pokeapi.v1.ListFavoritesRequest.or:type_name -> pokeapi.v1.FilterGroup
+	3,  // 8: pokeapi.v1.ListFavoritesResponse.items:type_name -> pokeapi.v1.Favorite
+	1,  // 9: pokeapi.v1.UserService.GetUser:input_type -> pokeapi.v1.GetUserRequest
+	4,  // 10: pokeapi.v1.FavoriteService.GetFavorite:input_type -> pokeapi.v1.GetFavoriteRequest
+	7,  // 11: pokeapi.v1.FavoriteService.ListFavorites:input_type -> pokeapi.v1.ListFavoritesRequest
+	9,  // 12: pokeapi.v1.FavoriteService.CreateFavorite:input_type -> pokeapi.v1.CreateFavoriteRequest
+	10, // 13: pokeapi.v1.FavoriteService.UpdateFavorite:input_type -> pokeapi.v1.UpdateFavoriteRequest
+	11, // 14: pokeapi.v1.FavoriteService.DeleteFavorite:input_type -> pokeapi.v1.DeleteFavoriteRequest
+	0,  // 15: pokeapi.v1.UserService.GetUser:output_type -> pokeapi.v1.User
+	3,  // 16: pokeapi.v1.FavoriteService.GetFavorite:output_type -> pokeapi.v1.Favorite
+	8,  // 17: pokeapi.v1.FavoriteService.ListFavorites:output_type -> pokeapi.v1.ListFavoritesResponse
+	3,  // 18: pokeapi.v1.FavoriteService.CreateFavorite:output_type -> pokeapi.v1.Favorite
+	3,  // 19: pokeapi.v1.FavoriteService.UpdateFavorite:output_type -> pokeapi.v1.Favorite
+	3,  // 20: pokeapi.v1.FavoriteService.DeleteFavorite:output_type -> pokeapi.v1.Favorite
+	15, // [15:21] is the sub-list for method output_type
+	9,  // [9:15] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_pokeapi_v1_pokeapi_proto_init() }
+func file_pokeapi_v1_pokeapi_proto_init() {
+	if File_pokeapi_v1_pokeapi_proto != nil {
+		return
+	}
+	file_pokeapi_v1_pokeapi_proto_msgTypes[1].OneofWrappers = []any{
+		(*GetUserRequest_Id)(nil),
+		(*GetUserRequest_Email)(nil),
+	}
+	file_pokeapi_v1_pokeapi_proto_msgTypes[7].OneofWrappers = []any{}
+	file_pokeapi_v1_pokeapi_proto_msgTypes[10].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pokeapi_v1_pokeapi_proto_rawDesc), len(file_pokeapi_v1_pokeapi_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_pokeapi_v1_pokeapi_proto_goTypes,
+		DependencyIndexes: file_pokeapi_v1_pokeapi_proto_depIdxs,
+		MessageInfos:      file_pokeapi_v1_pokeapi_proto_msgTypes,
+	}.Build()
+	File_pokeapi_v1_pokeapi_proto = out.File
+	file_pokeapi_v1_pokeapi_proto_goTypes = nil
+	file_pokeapi_v1_pokeapi_proto_depIdxs = nil
+}
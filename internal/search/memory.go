@@ -0,0 +1,318 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// field weights, a match in the name counts more than one in the notes
+var fieldWeights = map[string]float64{
+	"name":  3,
+	"tags":  2,
+	"types": 2,
+	"notes": 1,
+}
+
+// fuzzy matches below this trigram similarity are ignored, as pg_trgm does
+const similarityThreshold = 0.3
+
+// MemoryIndex is an inverted index held in memory. Terms match exactly, by
+// prefix or by trigram similarity, so typos still find the document.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]Document
+	postings map[string]map[string]float64 // term to document to weighted frequency
+	trigrams map[string]map[string]bool    // trigram to terms
+}
+
+// NewMemoryIndex returns an empty index
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     map[string]Document{},
+		postings: map[string]map[string]float64{},
+		trigrams: map[string]map[string]bool{},
+	}
+}
+
+// Index adds the documents or replaces their previous version
+func (m *MemoryIndex) Index(ctx context.Context, docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, doc := range docs {
+		key := docKey(doc.Kind, doc.ID)
+		m.remove(key)
+		m.docs[key] = doc
+		for term, weight := range documentTerms(doc) {
+			if m.postings[term] == nil {
+				m.postings[term] = map[string]float64{}
+				for _, trigram := range termTrigrams(term) {
+					if m.trigrams[trigram] == nil {
+						m.trigrams[trigram] = map[string]bool{}
+					}
+					m.trigrams[trigram][term] = true
+				}
+			}
+			m.postings[term][key] = weight
+		}
+	}
+	return nil
+}
+
+// Remove drops a document
+func (m *MemoryIndex) Remove(ctx context.Context, kind string, id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(docKey(kind, id))
+	return nil
+}
+
+func (m *MemoryIndex) remove(key string) {
+	doc, ok := m.docs[key]
+	if !ok {
+		return
+	}
+	delete(m.docs, key)
+	for term := range documentTerms(doc) {
+		delete(m.postings[term], key)
+		if len(m.postings[term]) > 0 {
+			continue
+		}
+		delete(m.postings, term)
+		for _, trigram := range termTrigrams(term) {
+			delete(m.trigrams[trigram], term)
+			if len(m.trigrams[trigram]) == 0 {
+				delete(m.trigrams, trigram)
+			}
+		}
+	}
+}
+
+// Search ranks the documents matching every word of the text. A word
+// scores the best of its matching terms, by similarity, weight and rarity.
+func (m *MemoryIndex) Search(ctx context.Context, q Query) (Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	words := tokens(q.Text)
+	if len(words) == 0 {
+		return Result{Hits: []Hit{}, Facets: []Facet{}}, nil
+	}
+
+	scores := map[string]float64{}
+	matched := map[string]map[string]bool{} // document to its matched terms
+	for i, word := range words {
+		wordScores := map[string]float64{}
+		for term, similarity := range m.candidates(word) {
+			idf := math.Log(1 + float64(len(m.docs))/float64(len(m.postings[term])))
+			for key, weight := range m.postings[term] {
+				if i > 0 && scores[key] == 0 {
+					continue // missed an earlier word
+				}
+				if score := similarity * weight * idf; score > wordScores[key] {
+					wordScores[key] = score
+				}
+				if matched[key] == nil {
+					matched[key] = map[string]bool{}
+				}
+				matched[key][term] = true
+			}
+		}
+		// every word must match
+		for key := range scores {
+			if wordScores[key] == 0 {
+				delete(scores, key)
+			}
+		}
+		for key, score := range wordScores {
+			if i == 0 || scores[key] > 0 {
+				scores[key] += score
+			}
+		}
+	}
+
+	// filter by kind and owner, count the facets, then filter by type
+	kinds := stringSet(q.Kinds)
+	types := stringSet(q.Types)
+	facets := map[string]int64{}
+	hits := []Hit{}
+	for key, score := range scores {
+		doc := m.docs[key]
+		if len(kinds) > 0 && !kinds[doc.Kind] {
+			continue
+		}
+		if q.Owner != "" && doc.Kind == Favorite && doc.Owner != q.Owner {
+			continue
+		}
+		hasType := len(types) == 0
+		for _, t := range doc.Types {
+			facets[t]++
+			hasType = hasType || types[t]
+		}
+		if hasType {
+			hits = append(hits, Hit{Document: doc, Score: math.Round(score*1000) / 1000})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Name != hits[j].Name {
+			return hits[i].Name < hits[j].Name
+		}
+		return docKey(hits[i].Kind, hits[i].ID) < docKey(hits[j].Kind, hits[j].ID)
+	})
+
+	result := Result{Total: int64(len(hits)), Facets: sortFacets(facets)}
+	start := min(q.Offset, len(hits))
+	end := len(hits)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, len(hits))
+	}
+	result.Hits = hits[start:end]
+	for i := range result.Hits {
+		result.Hits[i].Highlights = highlights(result.Hits[i].Document, matched[docKey(result.Hits[i].Kind, result.Hits[i].ID)])
+	}
+	return result, nil
+}
+
+// candidates are the indexed terms a word may stand for, with their
+// similarity to it
+func (m *MemoryIndex) candidates(word string) map[string]float64 {
+	found := map[string]float64{}
+	if m.postings[word] != nil {
+		found[word] = 1
+	}
+
+	// terms sharing trigrams with the word
+	shared := map[string]int{}
+	wordTrigrams := termTrigrams(word)
+	for _, trigram := range wordTrigrams {
+		for term := range m.trigrams[trigram] {
+			shared[term]++
+		}
+	}
+	for term, count := range shared {
+		if term == word {
+			continue
+		}
+		similarity := float64(count) / float64(len(wordTrigrams)+len(termTrigrams(term))-count)
+		if len([]rune(word)) >= 3 && strings.HasPrefix(term, word) {
+			similarity = math.Max(similarity, 0.8)
+		}
+		if similarity >= similarityThreshold {
+			found[term] = similarity
+		}
+	}
+	return found
+}
+
+// documentTerms are the terms of a document with their weighted frequency
+func documentTerms(doc Document) map[string]float64 {
+	terms := map[string]float64{}
+	add := func(field, text string) {
+		for _, term := range tokens(text) {
+			terms[term] += fieldWeights[field]
+		}
+	}
+	add("name", doc.Name)
+	add("notes", doc.Notes)
+	add("tags", strings.Join(doc.Tags, " "))
+	add("types", strings.Join(doc.Types, " "))
+	return terms
+}
+
+// highlights marks the matched terms in the name, notes and tags
+func highlights(doc Document, terms map[string]bool) map[string]string {
+	marked := map[string]string{}
+	for field, text := range map[string]string{"name": doc.Name, "notes": doc.Notes, "tags": strings.Join(doc.Tags, ", ")} {
+		if highlighted, ok := highlight(text, terms); ok {
+			marked[field] = highlighted
+		}
+	}
+	return marked
+}
+
+func highlight(text string, terms map[string]bool) (string, bool) {
+	var b strings.Builder
+	found := false
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		if terms[strings.ToLower(word)] {
+			found = true
+			b.WriteString(MarkStart + word + MarkEnd)
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+	return b.String(), found
+}
+
+// tokens splits text into lower case words
+func tokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !isWordRune(r) })
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// termTrigrams are the distinct trigrams of a term padded like pg_trgm
+// does, two spaces before and one after
+func termTrigrams(term string) []string {
+	runes := []rune("  " + term + " ")
+	seen := map[string]bool{}
+	var trigrams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		trigram := string(runes[i : i+3])
+		if !seen[trigram] {
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+	return trigrams
+}
+
+func docKey(kind string, id uint) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
+
+func stringSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// sortFacets orders the facets by count, then by value
+func sortFacets(counts map[string]int64) []Facet {
+	facets := make([]Facet, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, Facet{Value: value, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	return facets
+}
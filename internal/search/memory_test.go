@@ -0,0 +1,138 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newTestIndex(t *testing.T) *MemoryIndex {
+	t.Helper()
+	index := NewMemoryIndex()
+	err := index.Index(context.Background(),
+		Document{Kind: Species, ID: 4, Name: "charmander", Types: []string{"fire"}},
+		Document{Kind: Species, ID: 6, Name: "charizard", Types: []string{"fire", "flying"}},
+		Document{Kind: Species, ID: 7, Name: "squirtle", Types: []string{"water"}},
+		Document{Kind: Favorite, ID: 1, Owner: "ash@example.com", Name: "charizard", Types: []string{"fire", "flying"}, Notes: "first catch", Tags: []string{"team"}},
+		Document{Kind: Favorite, ID: 2, Owner: "misty@example.com", Name: "starmie", Types: []string{"water", "psychic"}, Notes: "charizard rival"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+// hitKeys lists the hits as kind:id, in order
+func hitKeys(result Result) []string {
+	keys := make([]string, len(result.Hits))
+	for i, hit := range result.Hits {
+		keys[i] = docKey(hit.Kind, hit.ID)
+	}
+	return keys
+}
+
+func TestMemoryIndexRanksNamesAboveNotes(t *testing.T) {
+	index := newTestIndex(t)
+	result, err := index.Search(context.Background(), Query{Text: "charizard"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := hitKeys(result)
+	if len(keys) < 3 || keys[len(keys)-1] != docKey(Favorite, 2) {
+		t.Errorf("hits = %v, want the note match last", keys)
+	}
+}
+
+func TestMemoryIndexToleratesTyposAndPrefixes(t *testing.T) {
+	index := newTestIndex(t)
+	for _, text := range []string{"charzard", "chari", "squirtel"} {
+		result, err := index.Search(context.Background(), Query{Text: text, Kinds: []string{Species}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Hits) == 0 {
+			t.Errorf("%q found nothing", text)
+		}
+	}
+}
+
+func TestMemoryIndexRequiresEveryWord(t *testing.T) {
+	index := newTestIndex(t)
+	result, _ := index.Search(context.Background(), Query{Text: "charizard team"})
+	if keys := hitKeys(result); len(keys) != 1 || keys[0] != docKey(Favorite, 1) {
+		t.Errorf("hits = %v, want only favorite 1", keys)
+	}
+}
+
+func TestMemoryIndexFiltersAndFacets(t *testing.T) {
+	index := newTestIndex(t)
+	result, _ := index.Search(context.Background(), Query{Text: "charizard", Owner: "ash@example.com", Types: []string{"water"}})
+	if len(result.Hits) != 0 {
+		t.Errorf("hits = %v, want none of type water", hitKeys(result))
+	}
+	// the facets are counted before the type filter
+	counts := map[string]int64{}
+	for _, facet := range result.Facets {
+		counts[facet.Value] = facet.Count
+	}
+	if counts["fire"] != 2 || counts["flying"] != 2 {
+		t.Errorf("facets = %v", result.Facets)
+	}
+
+	result, _ = index.Search(context.Background(), Query{Text: "charizard", Owner: "misty@example.com", Kinds: []string{Favorite}})
+	if keys := hitKeys(result); len(keys) != 1 || keys[0] != docKey(Favorite, 2) {
+		t.Errorf("hits = %v, want only misty's favorite", keys)
+	}
+}
+
+func TestMemoryIndexReplacesAndRemoves(t *testing.T) {
+	index := newTestIndex(t)
+	ctx := context.Background()
+	index.Index(ctx, Document{Kind: Favorite, ID: 1, Owner: "ash@example.com", Name: "pikachu"})
+	if result, _ := index.Search(ctx, Query{Text: "team", Kinds: []string{Favorite}}); len(result.Hits) != 0 {
+		t.Errorf("old version still found: %v", hitKeys(result))
+	}
+	index.Remove(ctx, Favorite, 1)
+	if result, _ := index.Search(ctx, Query{Text: "pikachu"}); len(result.Hits) != 0 {
+		t.Errorf("removed document still found: %v", hitKeys(result))
+	}
+}
+
+func TestMemoryIndexPages(t *testing.T) {
+	index := newTestIndex(t)
+	all, _ := index.Search(context.Background(), Query{Text: "char"})
+	page, _ := index.Search(context.Background(), Query{Text: "char", Offset: 1, Limit: 1})
+	if page.Total != all.Total || len(page.Hits) != 1 || page.Hits[0].ID != all.Hits[1].ID {
+		t.Errorf("page = %v of %d, want %v", hitKeys(page), page.Total, hitKeys(all)[1:2])
+	}
+}
+
+func TestHighlightEscapesHTML(t *testing.T) {
+	got, ok := highlight(`<img src=x onerror="alert(1)"> charizard & co`, map[string]bool{"charizard": true, "img": true})
+	want := `&lt;<mark>img</mark> src=x onerror=&#34;alert(1)&#34;&gt; <mark>charizard</mark> &amp; co`
+	if !ok || got != want {
+		t.Errorf("highlight = %q, want %q", got, want)
+	}
+}
+
+func TestEscapeHeadline(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+		marked   bool
+	}{
+		{"no match <b>", "no match &lt;b&gt;", false},
+		{"\x01char\x02izard <script>", "<mark>char</mark>izard &lt;script&gt;", true},
+		{"stray \x02 and \x01open", "stray  and <mark>open</mark>", true},
+		{"\x01a\x01b\x02", "<mark>ab</mark>", true},
+	}
+	for _, tt := range tests {
+		got, marked := escapeHeadline(tt.headline)
+		if got != tt.want || marked != tt.marked {
+			t.Errorf("escapeHeadline(%q) = %q, %v, want %q, %v", tt.headline, got, marked, tt.want, tt.marked)
+		}
+		if strings.Count(got, MarkStart) != strings.Count(got, MarkEnd) {
+			t.Errorf("escapeHeadline(%q) = %q has unbalanced marks", tt.headline, got)
+		}
+	}
+}
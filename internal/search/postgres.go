@@ -0,0 +1,242 @@
+package search
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// PostgresIndex searches the tables through Postgres full-text search and
+// pg_trgm similarity, so it needs no indexing of its own. Migrate creates
+// the extension and the GIN indexes the queries use.
+type PostgresIndex struct {
+	db *gorm.DB
+}
+
+// NewPostgresIndex returns an index reading db
+func NewPostgresIndex(db *gorm.DB) *PostgresIndex {
+	return &PostgresIndex{db: db}
+}
+
+// favoriteVector and speciesVector are the searched documents, the indexes
+// are built on the same expressions
+func favoriteVector(alias string) string {
+	return "to_tsvector('simple', coalesce(" + alias + "name, '') || ' ' || coalesce(" + alias + "type, '') || ' ' || coalesce(" + alias + "notes, ''))"
+}
+
+func speciesVector(alias string) string {
+	return "to_tsvector('simple', coalesce(" + alias + "name, '') || ' ' || replace(coalesce(" + alias + "types, ''), '/', ' '))"
+}
+
+// Migrate creates the pg_trgm extension and the search indexes
+func (p *PostgresIndex) Migrate() error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_pokemons_search ON pokemons USING GIN (" + favoriteVector("") + ")",
+		"CREATE INDEX IF NOT EXISTS idx_pokemons_name_trgm ON pokemons USING GIN (name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_species_search ON species USING GIN (" + speciesVector("") + ")",
+		"CREATE INDEX IF NOT EXISTS idx_species_name_trgm ON species USING GIN (name gin_trgm_ops)",
+	}
+	for _, statement := range statements {
+		if err := p.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Index does nothing, the tables are the index
+func (p *PostgresIndex) Index(ctx context.Context, docs ...Document) error { return nil }
+
+// Remove does nothing, the tables are the index
+func (p *PostgresIndex) Remove(ctx context.Context, kind string, id uint) error { return nil }
+
+// the hits of a kind: the document matches every word as a prefix, or the
+// name is close to the text. The rank weighs the name over the tags and
+// types, and those over the notes.
+var hitQueries = map[string]string{
+	Favorite: `
+SELECT 'favorite' AS kind, p.id, p.user_email AS owner, p.name, coalesce(p.type, '') AS types,
+	coalesce(p.notes, '') AS notes, coalesce(tg.tags, '') AS tags,
+	ts_rank(setweight(to_tsvector('simple', coalesce(p.name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(tg.tags, '') || ' ' || coalesce(p.type, '')), 'B') ||
+		setweight(to_tsvector('simple', coalesce(p.notes, '')), 'C'), q.query)
+		+ greatest(similarity(p.name, @text), word_similarity(@text, p.name)) AS score,
+	ts_headline('simple', p.name, q.query, @whole) AS name_highlight,
+	ts_headline('simple', coalesce(p.notes, ''), q.query, @fragments) AS notes_highlight,
+	ts_headline('simple', coalesce(tg.tags, ''), q.query, @whole) AS tags_highlight
+FROM pokemons p
+CROSS JOIN q
+LEFT JOIN LATERAL (
+	SELECT string_agg(t.name, ', ' ORDER BY t.name) AS tags
+	FROM pokemon_tags pt JOIN tags t ON t.id = pt.tag_id
+	WHERE pt.pokemon_id = p.id AND t.deleted_at IS NULL
+) tg ON true
+WHERE p.deleted_at IS NULL
+	AND (@owner = '' OR p.user_email = @owner)
+	AND (` + favoriteVector("p.") + ` @@ q.query
+		OR to_tsvector('simple', coalesce(tg.tags, '')) @@ q.query
+		OR p.name % @text
+		OR @text <% p.name)`,
+	Species: `
+SELECT 'species' AS kind, s.id, '' AS owner, s.name, coalesce(s.types, '') AS types,
+	'' AS notes, '' AS tags,
+	ts_rank(setweight(to_tsvector('simple', coalesce(s.name, '')), 'A') ||
+		setweight(to_tsvector('simple', replace(coalesce(s.types, ''), '/', ' ')), 'B'), q.query)
+		+ greatest(similarity(s.name, @text), word_similarity(@text, s.name)) AS score,
+	ts_headline('simple', s.name, q.query, @whole) AS name_highlight,
+	'' AS notes_highlight, '' AS tags_highlight
+FROM species s
+CROSS JOIN q
+WHERE ` + speciesVector("s.") + ` @@ q.query
+	OR s.name % @text
+	OR @text <% s.name`,
+}
+
+// the types of a hit, favorites may separate them by commas
+const hitTypes = `regexp_split_to_array(lower(types), '\s*[/,]\s*')`
+
+// pgHit is a row of the hits query
+type pgHit struct {
+	Kind           string
+	ID             uint
+	Owner          string
+	Name           string
+	Types          string
+	Notes          string
+	Tags           string
+	Score          float64
+	NameHighlight  string
+	NotesHighlight string
+	TagsHighlight  string
+	Total          int64
+}
+
+// Search runs the hits query for the page and for the facets
+func (p *PostgresIndex) Search(ctx context.Context, q Query) (Result, error) {
+	result := Result{Hits: []Hit{}, Facets: []Facet{}}
+	words := tokens(q.Text)
+	if len(words) == 0 {
+		return result, nil
+	}
+
+	// every word is matched as a prefix: "char & fire" -> "char:* & fire:*"
+	prefixes := make([]string, len(words))
+	for i, word := range words {
+		prefixes[i] = word + ":*"
+	}
+
+	kinds := q.Kinds
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+	var parts []string
+	for _, kind := range kinds {
+		if query, ok := hitQueries[kind]; ok {
+			parts = append(parts, query)
+		}
+	}
+	if len(parts) == 0 {
+		return result, nil
+	}
+	hits := "WITH q AS (SELECT to_tsquery('simple', @query) AS query), hits AS (" + strings.Join(parts, "\nUNION ALL\n") + ")\n"
+	args := map[string]interface{}{
+		"query":     strings.Join(prefixes, " & "),
+		"text":      strings.ToLower(strings.TrimSpace(q.Text)),
+		"owner":     q.Owner,
+		"whole":     `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `", HighlightAll=true`,
+		"fragments": `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `", MaxFragments=2, MaxWords=20, MinWords=5`,
+		"any_type":  len(q.Types) == 0,
+		"types":     strings.Join(q.Types, ","),
+		"limit":     q.Limit,
+		"offset":    q.Offset,
+	}
+	db := p.db.WithContext(ctx)
+
+	var rows []pgHit
+	err := db.Raw(hits+`SELECT hits.*, count(*) OVER () AS total FROM hits
+WHERE @any_type OR `+hitTypes+` && string_to_array(@types, ',')
+ORDER BY score DESC, name, kind, id
+LIMIT @limit OFFSET @offset`, args).Scan(&rows).Error
+	if err != nil {
+		return result, err
+	}
+
+	err = db.Raw(hits+`SELECT t AS value, count(*) AS count FROM hits, unnest(`+hitTypes+`) AS t
+WHERE t <> ''
+GROUP BY t
+ORDER BY count DESC, value`, args).Scan(&result.Facets).Error
+	if err != nil {
+		return result, err
+	}
+
+	for _, row := range rows {
+		result.Total = row.Total
+		hit := Hit{
+			Document: Document{
+				Kind:  row.Kind,
+				ID:    row.ID,
+				Owner: row.Owner,
+				Name:  row.Name,
+				Types: splitTypes(row.Types),
+				Notes: row.Notes,
+			},
+			Score:      row.Score,
+			Highlights: map[string]string{},
+		}
+		if row.Tags != "" {
+			hit.Tags = strings.Split(row.Tags, ", ")
+		}
+		// ts_headline returns the text as is when nothing matched
+		for field, headline := range map[string]string{"name": row.NameHighlight, "notes": row.NotesHighlight, "tags": row.TagsHighlight} {
+			if highlighted, ok := escapeHeadline(headline); ok {
+				hit.Highlights[field] = highlighted
+			}
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	if len(rows) == 0 && q.Offset > 0 {
+		// past the last page, count the hits anyway
+		var total int64
+		db.Raw(hits+"SELECT count(*) FROM hits WHERE @any_type OR "+hitTypes+" && string_to_array(@types, ',')", args).Scan(&total)
+		result.Total = total
+	}
+	return result, nil
+}
+
+// ts_headline wraps the matches in these control characters, the text
+// between them is escaped before they are replaced by the marks
+const (
+	headlineStart = "\x01"
+	headlineStop  = "\x02"
+)
+
+// escapeHeadline HTML escapes a ts_headline result and marks its matches,
+// a stray control character from the text itself is dropped
+func escapeHeadline(headline string) (string, bool) {
+	var b strings.Builder
+	marked, open := false, false
+	for {
+		i := strings.IndexAny(headline, headlineStart+headlineStop)
+		if i < 0 {
+			b.WriteString(html.EscapeString(headline))
+			break
+		}
+		b.WriteString(html.EscapeString(headline[:i]))
+		switch {
+		case headline[i] == headlineStart[0] && !open:
+			b.WriteString(MarkStart)
+			open, marked = true, true
+		case headline[i] == headlineStop[0] && open:
+			b.WriteString(MarkEnd)
+			open = false
+		}
+		headline = headline[i+1:]
+	}
+	if open {
+		b.WriteString(MarkEnd)
+	}
+	return b.String(), marked
+}
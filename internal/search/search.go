@@ -0,0 +1,204 @@
+// Package search ranks favorites and species against free text, with typo
+// tolerance. The Postgres index queries the tables through tsvector and
+// trigram indexes, the memory index serves other databases and tests.
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-api/config"
+	"go-api/internal/events"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of documents
+const (
+	Favorite = "favorite"
+	Species  = "species"
+)
+
+// Kinds lists every kind of document
+var Kinds = []string{Favorite, Species}
+
+// Highlighted terms are wrapped in these marks. The rest of a highlight is
+// HTML escaped, so a highlight is safe HTML.
+const (
+	MarkStart = "<mark>"
+	MarkEnd   = "</mark>"
+)
+
+// Document is a searchable favorite or species
+type Document struct {
+	Kind  string   `json:"kind"`
+	ID    uint     `json:"id"`
+	Owner string   `json:"owner,omitempty"` // email of the owner of a favorite
+	Name  string   `json:"name"`
+	Types []string `json:"types"`
+	Notes string   `json:"notes,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// Query is a search request
+type Query struct {
+	Text   string
+	Kinds  []string // empty searches every kind
+	Types  []string // a hit must have one of them, empty for any type
+	Owner  string   // only the favorites of this owner, empty for everyone's
+	Offset int
+	Limit  int
+}
+
+// Hit is a matching document with its relevance
+type Hit struct {
+	Document
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"` // field to escaped HTML with the matches marked
+}
+
+// Facet counts the hits having a type
+type Facet struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Result is a page of hits. The facets count every hit of the text before
+// the type filter, so the other types can still be offered.
+type Result struct {
+	Hits   []Hit
+	Total  int64
+	Facets []Facet
+}
+
+// SearchIndex finds documents by relevance. Index and Remove keep an index
+// up to date, indexes reading the tables themselves ignore them.
+type SearchIndex interface {
+	Index(ctx context.Context, docs ...Document) error
+	Remove(ctx context.Context, kind string, id uint) error
+	Search(ctx context.Context, q Query) (Result, error)
+}
+
+// Default is the index used by the search endpoint
+var Default SearchIndex
+
+// Setup picks the index from SEARCH_BACKEND (postgres or memory), by
+// default Postgres when the database is Postgres. The memory index is
+// loaded from the database and follows the favorite events.
+func Setup(db *gorm.DB) error {
+	backend := config.GetEnv("SEARCH_BACKEND")
+	if backend == "" {
+		backend = "memory" // Default for other databases
+		if db.Dialector.Name() == "postgres" {
+			backend = "postgres"
+		}
+	}
+
+	if backend == "postgres" {
+		index := NewPostgresIndex(db)
+		if err := index.Migrate(); err != nil {
+			return err
+		}
+		Default = index
+		return nil
+	}
+
+	index := NewMemoryIndex()
+	if err := Load(context.Background(), db, index); err != nil {
+		return err
+	}
+	Follow(db, index)
+	Default = index
+	return nil
+}
+
+// Load indexes every species and favorite
+func Load(ctx context.Context, db *gorm.DB, index SearchIndex) error {
+	var species []models.Species
+	if err := db.Find(&species).Error; err != nil {
+		return err
+	}
+	docs := make([]Document, len(species))
+	for i, s := range species {
+		docs[i] = SpeciesDocument(s)
+	}
+	if err := index.Index(ctx, docs...); err != nil {
+		return err
+	}
+
+	var pokemons []models.Pokemon
+	return db.Preload("Tags").FindInBatches(&pokemons, 500, func(tx *gorm.DB, batch int) error {
+		docs := make([]Document, len(pokemons))
+		for i, p := range pokemons {
+			docs[i] = FavoriteDocument(p)
+		}
+		return index.Index(ctx, docs...)
+	}).Error
+}
+
+// Follow updates the index on the favorite events. The favorite is read
+// again, so the index gets its tags and the latest state. The index is
+// local to the instance, so every instance follows the events.
+func Follow(db *gorm.DB, index SearchIndex) {
+	events.SubscribeLocal("search", func(e events.Event) error {
+		raw, err := json.Marshal(e.Payload)
+		if err != nil {
+			return nil
+		}
+		var changed struct{ ID uint }
+		if err := json.Unmarshal(raw, &changed); err != nil || changed.ID == 0 {
+			return nil
+		}
+
+		ctx := context.Background()
+		var pokemon models.Pokemon
+		err = db.Preload("Tags").First(&pokemon, changed.ID).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = index.Remove(ctx, Favorite, changed.ID)
+		case err == nil:
+			err = index.Index(ctx, FavoriteDocument(pokemon))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to index favorite %d: %w", changed.ID, err)
+		}
+		return nil
+	}, events.PokemonCreated, events.PokemonUpdated, events.PokemonDeleted)
+}
+
+// FavoriteDocument is the document of a favorite
+func FavoriteDocument(p models.Pokemon) Document {
+	tags := make([]string, len(p.Tags))
+	for i, tag := range p.Tags {
+		tags[i] = tag.Name
+	}
+	return Document{
+		Kind:  Favorite,
+		ID:    p.ID,
+		Owner: p.UserEmail,
+		Name:  p.Name,
+		Types: splitTypes(p.Type),
+		Notes: p.Notes,
+		Tags:  tags,
+	}
+}
+
+// SpeciesDocument is the document of a species
+func SpeciesDocument(s models.Species) Document {
+	return Document{Kind: Species, ID: s.ID, Name: s.Name, Types: splitTypes(s.Types)}
+}
+
+// splitTypes reads "grass/poison" or "grass, poison"
+func splitTypes(types string) []string {
+	var split []string
+	for _, t := range strings.FieldsFunc(strings.ToLower(types), func(r rune) bool { return r == '/' || r == ',' }) {
+		if t = strings.TrimSpace(t); t != "" {
+			split = append(split, t)
+		}
+	}
+	return split
+}
@@ -0,0 +1,140 @@
+package species
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go-api/config"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// formSuffix maps a form to the suffix used by the sprite host
+var formSuffix = map[string]string{
+	"alolan":     "alola",
+	"galarian":   "galar",
+	"hisuian":    "hisui",
+	"paldean":    "paldea",
+	"gigantamax": "gmax",
+	"mega":       "mega",
+}
+
+// entry is the shape of a species in the catalog file
+type entry struct {
+	ID                uint     `json:"id"`
+	Name              string   `json:"name"`
+	Types             []string `json:"types"`
+	Forms             []string `json:"forms"`
+	Genderless        bool     `json:"genderless"`
+	GenderDifferences bool     `json:"genderDifferences"`
+	Gigantamax        bool     `json:"gigantamax"`
+}
+
+// Seed upserts the species catalog file into the species table
+func Seed(db *gorm.DB, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var entries []entry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return fmt.Errorf("invalid species file: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	rows := make([]models.Species, 0, len(entries))
+	for _, e := range entries {
+		for _, form := range e.Forms {
+			if _, ok := formSuffix[form]; !ok || form == "gigantamax" {
+				return fmt.Errorf("species %q: unknown form %q", e.Name, form)
+			}
+		}
+		rows = append(rows, models.Species{
+			ID:                e.ID,
+			Name:              strings.ToLower(e.Name),
+			Types:             strings.Join(e.Types, "/"),
+			Forms:             strings.Join(e.Forms, ","),
+			Genderless:        e.Genderless,
+			GenderDifferences: e.GenderDifferences,
+			Gigantamax:        e.Gigantamax,
+		})
+	}
+
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error
+}
+
+// Find returns the species with the given name
+func Find(db *gorm.DB, name string) (*models.Species, error) {
+	var s models.Species
+	if err := db.Where("name = ?", strings.ToLower(strings.TrimSpace(name))).First(&s).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks the variant against the forms the species allows.
+// Unknown species are accepted as long as no form is requested.
+func Validate(db *gorm.DB, name, form, gender string) (*models.Species, error) {
+	s, err := Find(db, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if form != "" {
+			return nil, fmt.Errorf("unknown species %q cannot have form %q", name, form)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.AllowsForm(form) {
+		return s, fmt.Errorf("%s has no %s form", s.Name, form)
+	}
+	if s.Genderless && gender != "" && gender != "genderless" {
+		return s, fmt.Errorf("%s is genderless", s.Name)
+	}
+	if !s.Genderless && gender == "genderless" {
+		return s, fmt.Errorf("%s is not genderless", s.Name)
+	}
+	return s, nil
+}
+
+// SpriteURL picks the sprite matching the variant of a species
+func SpriteURL(s *models.Species, shiny bool, form, gender string) string {
+	baseURL := spriteBaseURL()
+
+	dir := "dex"
+	if shiny {
+		dir = "dex-shiny"
+	}
+
+	name := s.Name
+	if suffix, ok := formSuffix[form]; ok {
+		name += "-" + suffix
+	} else if gender == "female" && s.GenderDifferences {
+		name += "-f"
+	}
+
+	return fmt.Sprintf("%s/%s/%s.png", baseURL, dir, name)
+}
+
+// IsCatalogSprite reports whether the sprite was picked by SpriteURL, so it
+// can be replaced when the variant changes
+func IsCatalogSprite(sprite string) bool {
+	return strings.HasPrefix(sprite, spriteBaseURL()+"/")
+}
+
+func spriteBaseURL() string {
+	baseURL := config.GetEnv("SPRITE_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://play.pokemonshowdown.com/sprites" // Default sprite host
+	}
+	return strings.TrimRight(baseURL, "/")
+}
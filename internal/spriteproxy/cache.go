@@ -0,0 +1,193 @@
+package spriteproxy
+
+import (
+	"container/list"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Meta describes a cached image
+type Meta struct {
+	URL          string    `json:"url"`
+	ContentType  string    `json:"contentType"`
+	ETag         string    `json:"etag"`
+	UpstreamETag string    `json:"upstreamEtag"`
+	LastModified string    `json:"lastModified"`
+	Expires      time.Time `json:"expires"`
+	Size         int64     `json:"size"`
+}
+
+// Cache is a size capped disk cache with least recently used eviction
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	lru     *list.List // front is the most recently used
+	entries map[string]*list.Element
+	size    int64
+}
+
+type cacheEntry struct {
+	key  string
+	size int64
+}
+
+// NewCache opens the cache directory and indexes the existing entries
+func NewCache(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  map[string]*list.Element{},
+	}
+
+	// restore the recency order from the access times of previous runs
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	type found struct {
+		key     string
+		size    int64
+		touched time.Time
+	}
+	var existing []found
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		key := strings.TrimSuffix(filepath.Base(file), ".json")
+		meta, err := c.readMeta(key)
+		if err != nil {
+			c.remove(key)
+			continue
+		}
+		existing = append(existing, found{key: key, size: meta.Size, touched: info.ModTime()})
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i].touched.After(existing[j].touched) })
+	for _, e := range existing {
+		c.entries[e.key] = c.lru.PushBack(&cacheEntry{key: e.key, size: e.size})
+		c.size += e.size
+	}
+
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Get returns the cached image or nil when it is not cached
+func (c *Cache) Get(key string) (*Image, error) {
+	c.mu.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+
+	meta, err := c.readMeta(key)
+	if err != nil {
+		return nil, err
+	}
+	body, err := os.ReadFile(c.bodyPath(key))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	os.Chtimes(c.metaPath(key), now, now)
+	return &Image{Meta: meta, Body: body}, nil
+}
+
+// Put stores the image and evicts the least recently used entries
+// until the cache fits its size cap
+func (c *Cache) Put(key string, image *Image) error {
+	if image.Size > c.maxBytes {
+		return nil
+	}
+	meta, err := json.Marshal(image.Meta)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := writeFile(c.bodyPath(key), image.Body); err != nil {
+		return err
+	}
+	if err := writeFile(c.metaPath(key), meta); err != nil {
+		return err
+	}
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		c.size += image.Size - entry.size
+		entry.size = image.Size
+		c.lru.MoveToFront(element)
+	} else {
+		c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, size: image.Size})
+		c.size += image.Size
+	}
+	c.evict()
+	return nil
+}
+
+// evict must be called with the lock held
+func (c *Cache) evict() {
+	for c.size > c.maxBytes {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			return
+		}
+		entry := oldest.Value.(*cacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+		c.remove(entry.key)
+	}
+}
+
+func (c *Cache) readMeta(key string) (Meta, error) {
+	var meta Meta
+	raw, err := os.ReadFile(c.metaPath(key))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(raw, &meta)
+	return meta, err
+}
+
+func (c *Cache) remove(key string) {
+	os.Remove(c.bodyPath(key))
+	os.Remove(c.metaPath(key))
+}
+
+func (c *Cache) bodyPath(key string) string {
+	return filepath.Join(c.dir, key+".bin")
+}
+
+func (c *Cache) metaPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// writeFile replaces the file atomically
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,241 @@
+package spriteproxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api/config"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Errors returned by Proxy.Fetch
+var (
+	ErrInvalidURL     = errors.New("invalid sprite url")
+	ErrHostNotAllowed = errors.New("sprite host is not allowed")
+	ErrNotImage       = errors.New("upstream did not return an image")
+	ErrTooLarge       = errors.New("upstream image is too large")
+	ErrUpstream       = errors.New("failed to fetch upstream image")
+)
+
+// Upstream performs requests to sprite hosts, *http.Client satisfies it
+type Upstream interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Image is a cached upstream image
+type Image struct {
+	Meta
+	Body []byte
+}
+
+// Proxy fetches allow-listed sprites and keeps them in a disk cache
+type Proxy struct {
+	upstream     Upstream
+	cache        *Cache
+	allowedHosts map[string]bool
+	maxImage     int64
+	group        singleflight.Group
+}
+
+// Default is the configured sprite proxy
+var Default *Proxy
+
+// Setup initializes the default proxy from the environment
+func Setup() error {
+	dir := config.GetEnv("SPRITE_CACHE_DIR")
+	if dir == "" {
+		dir = "cache/sprites" // Default directory
+	}
+	maxBytes, err := strconv.ParseInt(config.GetEnv("SPRITE_CACHE_MAX_BYTES"), 10, 64)
+	if err != nil || maxBytes <= 0 {
+		maxBytes = 256 << 20 // Default 256 MiB
+	}
+	hosts := config.GetEnv("SPRITE_PROXY_ALLOWED_HOSTS")
+	if hosts == "" {
+		hosts = "raw.githubusercontent.com,play.pokemonshowdown.com,img.pokemondb.net"
+	}
+
+	cache, err := NewCache(dir, maxBytes)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	Default = New(client, cache, strings.Split(hosts, ","))
+	client.CheckRedirect = Default.CheckRedirect
+	return nil
+}
+
+// New creates a proxy with an injectable upstream
+func New(upstream Upstream, cache *Cache, allowedHosts []string) *Proxy {
+	allowed := map[string]bool{}
+	for _, host := range allowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			allowed[host] = true
+		}
+	}
+	return &Proxy{
+		upstream:     upstream,
+		cache:        cache,
+		allowedHosts: allowed,
+		maxImage:     5 << 20,
+	}
+}
+
+// Fetch returns the image for rawURL, from the cache when it is still fresh.
+// Concurrent fetches of the same URL share a single upstream request.
+func (p *Proxy) Fetch(ctx context.Context, rawURL string) (*Image, error) {
+	target, err := p.validate(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	key := cacheKey(target)
+
+	cached, _ := p.cache.Get(key)
+	if cached != nil && time.Now().Before(cached.Expires) {
+		return cached, nil
+	}
+
+	result, err, _ := p.group.Do(key, func() (interface{}, error) {
+		// the request must outlive the caller that started it
+		return p.fetch(context.WithoutCancel(ctx), key, target, cached)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Image), nil
+}
+
+func (p *Proxy) fetch(ctx context.Context, key, target string, cached *Image) (*Image, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	req.Header.Set("Accept", "image/*")
+
+	// revalidate a stale entry instead of downloading it again
+	if cached != nil {
+		if cached.UpstreamETag != "" {
+			req.Header.Set("If-None-Match", cached.UpstreamETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := p.upstream.Do(req)
+	if err != nil {
+		if cached != nil {
+			return cached, nil // serve stale when the upstream is down
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cached.Expires, _ = freshness(resp.Header)
+		p.cache.Put(key, cached)
+		return cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrUpstream, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, p.maxImage+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, err)
+	}
+	if int64(len(body)) > p.maxImage {
+		return nil, ErrTooLarge
+	}
+
+	// trust the bytes, not the upstream header
+	contentType := http.DetectContentType(body)
+	if mediaType, _, _ := mime.ParseMediaType(contentType); !strings.HasPrefix(mediaType, "image/") {
+		return nil, ErrNotImage
+	}
+
+	sum := sha256.Sum256(body)
+	expires, storable := freshness(resp.Header)
+	image := &Image{
+		Meta: Meta{
+			URL:          target,
+			ContentType:  contentType,
+			ETag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+			UpstreamETag: resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Expires:      expires,
+			Size:         int64(len(body)),
+		},
+		Body: body,
+	}
+	if storable {
+		p.cache.Put(key, image)
+	}
+	return image, nil
+}
+
+// CheckRedirect is the redirect policy of the upstream client, every hop
+// must stay on an allowed host
+func (p *Proxy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 5 {
+		return errors.New("stopped after 5 redirects")
+	}
+	_, err := p.validate(req.URL.String())
+	return err
+}
+
+func (p *Proxy) validate(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return "", ErrInvalidURL
+	}
+	if !p.allowedHosts[strings.ToLower(u.Hostname())] {
+		return "", ErrHostNotAllowed
+	}
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// freshness computes the expiry from the upstream cache headers and
+// reports whether the response may be stored at all
+func freshness(header http.Header) (time.Time, bool) {
+	now := time.Now()
+	defaultTTL := 24 * time.Hour
+
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return now, false
+		case directive == "no-cache":
+			return now, true
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				return now.Add(time.Duration(seconds) * time.Second), true
+			}
+		}
+	}
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return expires, true
+	}
+	return now.Add(defaultTTL), true
+}
+
+func cacheKey(target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "sprites/12/a.png", []byte("png"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	body, contentType, err := store.Get(ctx, "sprites/12/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "png" || contentType != "image/png" {
+		t.Errorf("Get = %q, %q", data, contentType)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sprites", "12", "a.png.tmp")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("temporary file left behind")
+	}
+
+	if err := store.Delete(ctx, "sprites/12/a.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Get(ctx, "sprites/12/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want %v", err, ErrNotFound)
+	}
+	if err := store.Delete(ctx, "sprites/12/a.png"); err != nil {
+		t.Errorf("Delete of a missing key = %v", err)
+	}
+}
+
+func TestLocalStorePutFile(t *testing.T) {
+	store, _ := NewLocalStore(t.TempDir())
+	source := filepath.Join(t.TempDir(), "export.csv")
+	os.WriteFile(source, []byte("id,name\n1,eevee\n"), 0o644)
+
+	if err := PutFile(context.Background(), store, "exports/1.csv", source, "text/csv"); err != nil {
+		t.Fatal(err)
+	}
+	body, _, err := store.Get(context.Background(), "exports/1.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	if data, _ := io.ReadAll(body); string(data) != "id,name\n1,eevee\n" {
+		t.Errorf("stored %q", data)
+	}
+}
+
+func TestLocalStoreRejectsTraversal(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "blobs")
+	store, _ := NewLocalStore(root)
+	for _, key := range []string{"", "/", "../secret", "sprites/../../secret", "a/.."} {
+		if err := store.Put(context.Background(), key, []byte("x"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) was accepted", key)
+		}
+	}
+	// an absolute key stays inside the root
+	store.Put(context.Background(), "/etc/passwd", []byte("x"), "text/plain")
+	if _, err := os.Stat(filepath.Join(root, "etc", "passwd")); err != nil {
+		t.Errorf("absolute key not kept under the root: %v", err)
+	}
+}
+
+func TestSignURL(t *testing.T) {
+	t.Setenv("BLOB_SIGNING_SECRET", "test-secret")
+	verify := func(key, signed string) bool {
+		query, _ := url.ParseQuery(signed[strings.Index(signed, "?")+1:])
+		return VerifySignature(key, query.Get("expires"), query.Get("signature"))
+	}
+	signed := SignURL("sprites/1/a.png", time.Minute)
+	if !verify("sprites/1/a.png", signed) {
+		t.Error("signed URL does not verify")
+	}
+	if verify("sprites/2/a.png", signed) {
+		t.Error("signature verifies for another key")
+	}
+	if verify("sprites/1/a.png", SignURL("sprites/1/a.png", -time.Minute)) {
+		t.Error("expired signature verifies")
+	}
+}
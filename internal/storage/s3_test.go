@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a MinIO-like server keeping objects in memory, it checks the
+// signature of every request the way S3 does
+type fakeS3 struct {
+	accessKey string
+	secretKey string
+	region    string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{accessKey: "minio", secretKey: "minio-secret", region: "eu-west-3", objects: map[string]fakeObject{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+var authorizationPattern = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=([a-z0-9;-]+), Signature=([0-9a-f]{64})$`)
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := f.verify(r, body); err != nil {
+		http.Error(w, "SignatureDoesNotMatch: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = fakeObject{data: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Write(object.data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// verify recomputes the signature version 4 of the request
+func (f *fakeS3) verify(r *http.Request, body []byte) error {
+	match := authorizationPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if match == nil {
+		return errors.New("malformed authorization")
+	}
+	accessKey, date, region, signedHeaders, signature := match[1], match[2], match[3], match[4], match[5]
+	if accessKey != f.accessKey || region != f.region {
+		return fmt.Errorf("unknown credential %s/%s", accessKey, region)
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil || !strings.HasPrefix(amzDate, date) || time.Since(signedAt).Abs() > 15*time.Minute {
+		return fmt.Errorf("bad date %q", amzDate)
+	}
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if sum := sha256.Sum256(body); payloadHash != "UNSIGNED-PAYLOAD" && payloadHash != hex.EncodeToString(sum[:]) {
+		return errors.New("payload hash mismatch")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(value))
+	}
+	canonicalRequest := strings.Join([]string{r.Method, canonicalURI(r.URL.Path), r.URL.RawQuery, canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, date + "/" + region + "/s3/aws4_request", hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := []byte("AWS4" + f.secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	if !hmac.Equal([]byte(hex.EncodeToString(key)), []byte(signature)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// canonicalURI encodes the path as S3 expects it in the canonical request
+func canonicalURI(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		escaped := strings.ReplaceAll(url.QueryEscape(segment), "+", "%20")
+		segments[i] = strings.ReplaceAll(escaped, "*", "%2A")
+	}
+	return strings.Join(segments, "/")
+}
+
+func newTestS3Store(t *testing.T, f *fakeS3, endpoint string) *S3Store {
+	t.Helper()
+	store, err := NewS3Store(S3Config{Endpoint: endpoint + "/", Region: f.region, Bucket: "sprites", AccessKey: f.accessKey, SecretKey: f.secretKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	f, srv := newFakeS3(t)
+	store := newTestS3Store(t, f, srv.URL)
+	ctx := context.Background()
+
+	key := "sprites/12/a b+c(1).png"
+	if err := store.Put(ctx, key, []byte("png"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.objects["/sprites/sprites/12/a b+c(1).png"]; !ok {
+		t.Errorf("objects = %v, want the key under the bucket", f.objects)
+	}
+
+	body, contentType, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "png" || contentType != "image/png" {
+		t.Errorf("Get = %q, %q", data, contentType)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want %v", err, ErrNotFound)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing key = %v", err)
+	}
+}
+
+func TestS3StorePutStream(t *testing.T) {
+	f, srv := newFakeS3(t)
+	store := newTestS3Store(t, f, srv.URL)
+	data := bytes.Repeat([]byte("row\n"), 1000)
+	if err := store.PutStream(context.Background(), "exports/1.csv", bytes.NewReader(data), int64(len(data)), "text/csv"); err != nil {
+		t.Fatal(err)
+	}
+	if object := f.objects["/sprites/exports/1.csv"]; !bytes.Equal(object.data, data) {
+		t.Errorf("stored %d bytes, want %d", len(object.data), len(data))
+	}
+}
+
+func TestS3StoreRejectedSignature(t *testing.T) {
+	f, srv := newFakeS3(t)
+	store := newTestS3Store(t, f, srv.URL)
+	store.cfg.SecretKey = "wrong"
+	err := store.Put(context.Background(), "sprites/1/a.png", []byte("png"), "image/png")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put = %v, want a 403", err)
+	}
+}
@@ -0,0 +1,115 @@
+package usage
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the latency buckets grow by 20% from 0.1ms, so a percentile read from
+// the histogram is within 10% of the exact one
+const (
+	minLatencyMs = 0.1
+	growth       = 1.2
+	maxBucket    = 100 // about 2 hours
+)
+
+// Histogram counts latencies per bucket. Histograms of several hours add
+// up, which the percentiles of each hour would not.
+type Histogram map[int]int64
+
+// Observe counts a latency in milliseconds
+func (h Histogram) Observe(ms float64) {
+	h[bucketOf(ms)]++
+}
+
+// Merge adds the counts of other
+func (h Histogram) Merge(other Histogram) {
+	for bucket, count := range other {
+		h[bucket] += count
+	}
+}
+
+// Quantile estimates the latency below which a share q of the requests
+// fall, as the geometric middle of its bucket
+func (h Histogram) Quantile(q float64) float64 {
+	var total int64
+	buckets := make([]int, 0, len(h))
+	for bucket, count := range h {
+		total += count
+		buckets = append(buckets, bucket)
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Ints(buckets)
+
+	rank := int64(math.Ceil(q * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for _, bucket := range buckets {
+		if seen += h[bucket]; seen >= rank {
+			return round(bucketMiddle(bucket))
+		}
+	}
+	return round(bucketMiddle(buckets[len(buckets)-1]))
+}
+
+// String encodes the histogram as "bucket:count" pairs
+func (h Histogram) String() string {
+	buckets := make([]int, 0, len(h))
+	for bucket := range h {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+	pairs := make([]string, len(buckets))
+	for i, bucket := range buckets {
+		pairs[i] = strconv.Itoa(bucket) + ":" + strconv.FormatInt(h[bucket], 10)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseHistogram decodes String, malformed pairs are skipped
+func ParseHistogram(encoded string) Histogram {
+	h := Histogram{}
+	for _, pair := range strings.Split(encoded, ",") {
+		bucket, count, ok := strings.Cut(pair, ":")
+		if !ok {
+			continue
+		}
+		b, err1 := strconv.Atoi(bucket)
+		n, err2 := strconv.ParseInt(count, 10, 64)
+		if err1 == nil && err2 == nil {
+			h[b] += n
+		}
+	}
+	return h
+}
+
+// bucketOf is the bucket of a latency, bucket i holds the latencies up to
+// minLatencyMs * growth^i
+func bucketOf(ms float64) int {
+	if ms <= minLatencyMs {
+		return 0
+	}
+	bucket := int(math.Ceil(math.Log(ms/minLatencyMs) / math.Log(growth)))
+	if bucket > maxBucket {
+		return maxBucket
+	}
+	return bucket
+}
+
+func bucketMiddle(bucket int) float64 {
+	if bucket == 0 {
+		return minLatencyMs
+	}
+	upper := minLatencyMs * math.Pow(growth, float64(bucket))
+	return upper / math.Sqrt(growth)
+}
+
+func round(ms float64) float64 {
+	return math.Round(ms*100) / 100
+}
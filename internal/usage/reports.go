@@ -0,0 +1,151 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Sort orders of the endpoints report
+const (
+	SortRequests  = "requests"
+	SortErrorRate = "error_rate"
+	SortP95       = "p95"
+)
+
+// ErrInvalidSort is returned for an unknown sort of the endpoints report
+var ErrInvalidSort = errors.New("sort must be requests, error_rate or p95")
+
+// ErrInvalidClientKind is returned for clients grouped by neither ip nor user
+var ErrInvalidClientKind = errors.New("by must be ip or user")
+
+// EndpointUsage is an endpoint of the endpoints report, latencies are in
+// milliseconds
+type EndpointUsage struct {
+	Method          string  `json:"method"`
+	Route           string  `json:"route"`
+	Requests        int64   `json:"requests"`
+	ClientErrors    int64   `json:"clientErrors"`
+	ServerErrors    int64   `json:"serverErrors"`
+	ErrorRate       float64 `json:"errorRate"`       // share of 5xx
+	ClientErrorRate float64 `json:"clientErrorRate"` // share of 4xx
+	AvgMs           float64 `json:"avgMs"`
+	P50Ms           float64 `json:"p50Ms"`
+	P95Ms           float64 `json:"p95Ms"`
+	P99Ms           float64 `json:"p99Ms"`
+	MaxMs           float64 `json:"maxMs"`
+}
+
+// ClientUsage is a client of the top clients report
+type ClientUsage struct {
+	Client    string  `json:"client"`
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"errorRate"` // share of 4xx and 5xx
+}
+
+// Endpoints reports the requests of every endpoint in the hours since the
+// hour of since
+func Endpoints(ctx context.Context, db *gorm.DB, since time.Time, sortBy string, limit int) ([]EndpointUsage, error) {
+	if sortBy != SortRequests && sortBy != SortErrorRate && sortBy != SortP95 {
+		return nil, ErrInvalidSort
+	}
+
+	type endpoint struct{ method, route string }
+	totals := map[endpoint]*models.RequestHour{}
+	histograms := map[endpoint]Histogram{}
+	var hours []models.RequestHour
+	err := db.WithContext(ctx).Where("hour >= ?", Hour(since)).
+		FindInBatches(&hours, 5000, func(tx *gorm.DB, batch int) error {
+			for _, h := range hours {
+				key := endpoint{h.Method, h.Route}
+				total := totals[key]
+				if total == nil {
+					total = &models.RequestHour{Method: h.Method, Route: h.Route}
+					totals[key] = total
+					histograms[key] = Histogram{}
+				}
+				total.Requests += h.Requests
+				total.ClientErrors += h.ClientErrors
+				total.ServerErrors += h.ServerErrors
+				total.DurationMs += h.DurationMs
+				total.MaxDurationMs = math.Max(total.MaxDurationMs, h.MaxDurationMs)
+				histograms[key].Merge(ParseHistogram(h.Latency))
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]EndpointUsage, 0, len(totals))
+	for key, total := range totals {
+		h := histograms[key]
+		usage = append(usage, EndpointUsage{
+			Method:          total.Method,
+			Route:           total.Route,
+			Requests:        total.Requests,
+			ClientErrors:    total.ClientErrors,
+			ServerErrors:    total.ServerErrors,
+			ErrorRate:       rate(total.ServerErrors, total.Requests),
+			ClientErrorRate: rate(total.ClientErrors, total.Requests),
+			AvgMs:           round(total.DurationMs / float64(total.Requests)),
+			P50Ms:           h.Quantile(0.50),
+			P95Ms:           h.Quantile(0.95),
+			P99Ms:           h.Quantile(0.99),
+			MaxMs:           round(total.MaxDurationMs),
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		switch {
+		case sortBy == SortErrorRate && a.ErrorRate != b.ErrorRate:
+			return a.ErrorRate > b.ErrorRate
+		case sortBy == SortP95 && a.P95Ms != b.P95Ms:
+			return a.P95Ms > b.P95Ms
+		case a.Requests != b.Requests:
+			return a.Requests > b.Requests
+		case a.Route != b.Route:
+			return a.Route < b.Route
+		}
+		return a.Method < b.Method
+	})
+	if len(usage) > limit {
+		usage = usage[:limit]
+	}
+	return usage, nil
+}
+
+// TopClients reports the clients, by ip or by user, with the most requests
+// in the hours since the hour of since
+func TopClients(ctx context.Context, db *gorm.DB, kind string, since time.Time, limit int) ([]ClientUsage, error) {
+	if kind != ByIP && kind != ByUser {
+		return nil, ErrInvalidClientKind
+	}
+
+	clients := []ClientUsage{}
+	err := db.WithContext(ctx).Model(&models.ClientHour{}).
+		Select("client, SUM(requests) AS requests, SUM(errors) AS errors").
+		Where("kind = ? AND hour >= ?", kind, Hour(since)).
+		Group("client").
+		Order("requests DESC, client").
+		Limit(limit).
+		Scan(&clients).Error
+	for i := range clients {
+		clients[i].ErrorRate = rate(clients[i].Errors, clients[i].Requests)
+	}
+	return clients, err
+}
+
+func rate(count, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)/float64(total)*10000) / 10000
+}
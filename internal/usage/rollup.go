@@ -0,0 +1,222 @@
+// Package usage reports how the API is used from the request log: requests,
+// error rates and latency percentiles per endpoint, and the top clients. A
+// refresh job rolls the log up per hour, the reports read the rollups.
+package usage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api/config"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Settings tune the refresh job
+type Settings struct {
+	Interval time.Duration // between two refreshes
+	Overlap  time.Duration // requests logged this late after the watermark are still seen
+}
+
+// LoadSettings reads the settings from the environment
+func LoadSettings() Settings {
+	return Settings{
+		Interval: config.GetEnvDuration("USAGE_REFRESH_INTERVAL", time.Minute),  // Default refresh
+		Overlap:  config.GetEnvDuration("USAGE_REFRESH_OVERLAP", 5*time.Minute), // Default overlap
+	}
+}
+
+// Client kinds
+const (
+	ByIP   = "ip"
+	ByUser = "user"
+)
+
+// the name of the requests rollup
+const requestsRollup = "requests"
+
+// Start refreshes the rollups now and then every Interval until ctx is done
+func Start(ctx context.Context, db *gorm.DB, s Settings) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := Refresh(ctx, db, s); err != nil {
+			log.Printf("usage: failed to refresh: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh recomputes the hours from the first request logged since the
+// last refresh up to now, the first one computes every hour. An hour is
+// recomputed from its requests, so refreshing twice is harmless.
+func Refresh(ctx context.Context, db *gorm.DB, s Settings) (models.AnalyticsRollup, error) {
+	db = db.WithContext(ctx)
+	started := time.Now()
+
+	rollup := models.AnalyticsRollup{Name: requestsRollup}
+	err := db.First(&rollup, "name = ?", requestsRollup).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return rollup, err
+	}
+	if err := backfill(db); err != nil {
+		return rollup, err
+	}
+
+	query := db.Model(&models.Log{})
+	if !rollup.Watermark.IsZero() {
+		query = query.Where("created_at > ?", rollup.Watermark.Add(-s.Overlap))
+	}
+	var first []time.Time
+	if err := query.Order("created_at").Limit(1).Pluck("created_at", &first).Error; err != nil {
+		return rollup, err
+	}
+
+	hours := 0
+	if len(first) > 0 {
+		for hour := Hour(first[0]); !hour.After(started); hour = hour.Add(time.Hour) {
+			if err := rollUpHour(db, hour); err != nil {
+				return rollup, err
+			}
+			hours++
+		}
+	}
+
+	rollup.Watermark = started
+	rollup.RefreshedAt = time.Now()
+	rollup.Days = hours
+	return rollup, db.Save(&rollup).Error
+}
+
+// rollUpHour replaces the rows of an hour by the counts of its requests
+func rollUpHour(db *gorm.DB, hour time.Time) error {
+	type endpoint struct{ method, route string }
+	type client struct{ kind, client string }
+	endpoints := map[endpoint]*models.RequestHour{}
+	histograms := map[endpoint]Histogram{}
+	clients := map[client]*models.ClientHour{}
+
+	count := func(key client, failed bool) {
+		row := clients[key]
+		if row == nil {
+			row = &models.ClientHour{Hour: hour, Kind: key.kind, Client: key.client}
+			clients[key] = row
+		}
+		row.Requests++
+		if failed {
+			row.Errors++
+		}
+	}
+
+	var rows []models.Log
+	err := db.Model(&models.Log{}).
+		Select("id, user_email, method, route, client_ip, status_code, duration_ms").
+		Where("created_at >= ? AND created_at < ?", hour, hour.Add(time.Hour)).
+		FindInBatches(&rows, 5000, func(tx *gorm.DB, batch int) error {
+			for _, r := range rows {
+				key := endpoint{r.Method, r.Route}
+				row := endpoints[key]
+				if row == nil {
+					row = &models.RequestHour{Hour: hour, Method: r.Method, Route: r.Route}
+					endpoints[key] = row
+					histograms[key] = Histogram{}
+				}
+				row.Requests++
+				if r.StatusCode >= 500 {
+					row.ServerErrors++
+				} else if r.StatusCode >= 400 {
+					row.ClientErrors++
+				}
+				row.DurationMs += r.DurationMs
+				if r.DurationMs > row.MaxDurationMs {
+					row.MaxDurationMs = r.DurationMs
+				}
+				histograms[key].Observe(r.DurationMs)
+
+				count(client{ByIP, r.ClientIP}, r.StatusCode >= 400)
+				if r.UserEmail != "" {
+					count(client{ByUser, r.UserEmail}, r.StatusCode >= 400)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	endpointRows := make([]models.RequestHour, 0, len(endpoints))
+	for key, row := range endpoints {
+		row.Latency = histograms[key].String()
+		endpointRows = append(endpointRows, *row)
+	}
+	clientRows := make([]models.ClientHour, 0, len(clients))
+	for _, row := range clients {
+		clientRows = append(clientRows, *row)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("hour = ?", hour).Delete(&models.RequestHour{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("hour = ?", hour).Delete(&models.ClientHour{}).Error; err != nil {
+			return err
+		}
+		if len(endpointRows) > 0 {
+			if err := tx.CreateInBatches(endpointRows, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(clientRows) > 0 {
+			return tx.CreateInBatches(clientRows, 500).Error
+		}
+		return nil
+	})
+}
+
+// backfill fills the route and the numeric duration of the requests logged
+// before they were recorded. The route is the path with its numeric
+// segments read as :id, which is what the routes use.
+func backfill(db *gorm.DB) error {
+	var legacy []models.Log
+	return db.Select("id, uri, duration").Where("route = '' OR route IS NULL").
+		FindInBatches(&legacy, 1000, func(tx *gorm.DB, batch int) error {
+			for _, entry := range legacy {
+				route := entry.URI
+				if parsed, err := url.Parse(entry.URI); err == nil {
+					route = parsed.Path
+				}
+				segments := strings.Split(route, "/")
+				for i, segment := range segments {
+					if _, err := strconv.ParseUint(segment, 10, 64); err == nil {
+						segments[i] = ":id"
+					}
+				}
+				route = strings.Join(segments, "/")
+				var ms float64
+				if d, err := time.ParseDuration(entry.Duration); err == nil {
+					ms = float64(d.Microseconds()) / 1000
+				}
+				err := db.Model(&models.Log{}).Where("id = ?", entry.ID).
+					Updates(map[string]interface{}{"route": route, "duration_ms": ms}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// Hour is the UTC hour of t
+func Hour(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"go-api/config"
+)
+
+// ErrInvalidCursor is returned for tampered or malformed cursors
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a keyset page, it is opaque to clients
+type Cursor struct {
+	Column   string      `json:"c"`
+	Desc     bool        `json:"d,omitempty"`
+	Value    interface{} `json:"v"`
+	ID       interface{} `json:"i"`
+	Backward bool        `json:"b,omitempty"`
+}
+
+// EncodeCursor serializes and signs the cursor
+func EncodeCursor(cursor Cursor) string {
+	payload, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + cursorSignature(encoded)
+}
+
+// DecodeCursor verifies the signature and restores the cursor
+func DecodeCursor(raw string) (*Cursor, error) {
+	encoded, signature, ok := strings.Cut(raw, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(cursorSignature(encoded))) {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil || cursor.Column == "" {
+		return nil, ErrInvalidCursor
+	}
+	cursor.Value = normalizeNumber(cursor.Value)
+	cursor.ID = normalizeNumber(cursor.ID)
+	return &cursor, nil
+}
+
+// normalizeNumber turns json numbers back into values the driver can bind
+func normalizeNumber(value interface{}) interface{} {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if i, err := number.Int64(); err == nil {
+		return i
+	}
+	f, _ := number.Float64()
+	return f
+}
+
+func cursorSignature(encoded string) string {
+	secret := config.GetEnv("CURSOR_SECRET")
+	if secret == "" {
+		secret = config.GetEnv("JWT_SECRET")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"context"
+	"errors"
+
+	"go-api/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// WithTx returns a context whose requests run their queries in tx
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// DB returns the database of the request, the surrounding transaction of a
+// batch when there is one
+func DB(c *gin.Context) *gorm.DB {
+	if tx, ok := c.Request.Context().Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return config.DB
+}
+
+// IsUniqueViolation reports whether err comes from a unique index, the
+// dialect of db translates its driver errors
+func IsUniqueViolation(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/unique.db"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type named struct {
+		ID   uint
+		Name string `gorm:"uniqueIndex"`
+	}
+	db.AutoMigrate(&named{})
+	db.Create(&named{Name: "one"})
+
+	err = db.Create(&named{Name: "one"}).Error
+	if err == nil || !IsUniqueViolation(db, err) {
+		t.Errorf("IsUniqueViolation(%v) = false", err)
+	}
+	if IsUniqueViolation(db, errors.New("connection refused")) {
+		t.Error("IsUniqueViolation of another error = true")
+	}
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-api/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned when the row changed since it was read
+var ErrVersionConflict = errors.New("resource was modified by another request")
+
+// ETag formats the version of a resource as a strong entity tag
+func ETag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// NotModified sets the ETag and answers 304 when If-None-Match matches.
+// It reports whether the response was written.
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if matchesETag(c.GetHeader("If-None-Match"), etag, true) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// CheckIfMatch validates the If-Match precondition of a write. A missing
+// header is rejected with 428 when REQUIRE_IF_MATCH is enabled, a stale one
+// with 412. It reports whether the request may proceed.
+func CheckIfMatch(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if config.GetEnv("REQUIRE_IF_MATCH") == "true" {
+			Response(c, http.StatusPreconditionRequired, false, "If-Match header is required", nil)
+			return false
+		}
+		return true
+	}
+
+	if !matchesETag(header, etag, false) {
+		c.Header("ETag", etag)
+		Response(c, http.StatusPreconditionFailed, false, "Resource was modified, fetch it again", nil)
+		return false
+	}
+	return true
+}
+
+// UpdateVersioned writes the columns of model only if its version is still
+// the one that was read, and bumps the version. No columns means all of them.
+func UpdateVersioned(db *gorm.DB, model interface{}, version *uint, columns ...string) error {
+	current := *version
+	*version = current + 1
+
+	query := db.Model(model).Where("version = ?", current)
+	if len(columns) == 0 {
+		query = query.Select("*").Omit("id", "created_at")
+	} else {
+		query = query.Select(append(columns, "version", "updated_at"))
+	}
+
+	result := query.Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		*version = current
+	}
+	return result.Error
+}
+
+// DeleteVersioned deletes model only if its version is still the one that was read
+func DeleteVersioned(db *gorm.DB, model interface{}, version uint) error {
+	result := db.Where("version = ?", version).Delete(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return result.Error
+}
+
+// VersionConflictResponse answers a lost update with 412
+func VersionConflictResponse(c *gin.Context) {
+	Response(c, http.StatusPreconditionFailed, false, ErrVersionConflict.Error(), nil)
+}
+
+// matchesETag compares a conditional header with the current entity tag.
+// If-None-Match uses the weak comparison, If-Match the strong one.
+func matchesETag(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
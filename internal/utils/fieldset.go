@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Fieldset is the parsed fields parameter, a nil Fieldset keeps every field
+type Fieldset struct {
+	columns []string
+	keys    map[string]bool
+}
+
+// ParseFieldset reads fields=a,b,c and checks every name against
+// allowedFields, which maps a response field to its column. Names are matched
+// ignoring case and underscores, so userEmail and user_email are the same.
+func ParseFieldset(c *gin.Context, allowedFields map[string]string) (*Fieldset, error) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil, nil
+	}
+
+	allowed := map[string]string{}
+	for field, column := range allowedFields {
+		allowed[fieldKey(field)] = column
+	}
+
+	fieldset := &Fieldset{keys: map[string]bool{}}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		column, ok := allowed[fieldKey(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, use one of %s", name, strings.Join(sortedKeys(allowedFields), ", "))
+		}
+		if !fieldset.keys[fieldKey(name)] {
+			fieldset.keys[fieldKey(name)] = true
+			fieldset.columns = append(fieldset.columns, column)
+		}
+	}
+	if len(fieldset.columns) == 0 {
+		return nil, fmt.Errorf("fields must name at least one field")
+	}
+	return fieldset, nil
+}
+
+// Select narrows the query to the requested columns, required lists the
+// columns the handler needs anyway (ids, cursor and relation keys)
+func (f *Fieldset) Select(db *gorm.DB, required ...string) *gorm.DB {
+	if f == nil {
+		return db
+	}
+	columns := append([]string{}, f.columns...)
+	for _, column := range required {
+		if !containsString(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return db.Select(columns)
+}
+
+// Project returns the item as a map holding the requested fields and the
+// extra keys (embedded resources)
+func (f *Fieldset) Project(item interface{}, extra ...string) (map[string]interface{}, error) {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return fields, nil
+	}
+
+	projected := map[string]interface{}{}
+	for key, value := range fields {
+		if f.keys[fieldKey(key)] || containsString(extra, key) {
+			projected[key] = value
+		}
+	}
+	return projected, nil
+}
+
+// ParseIncludes reads include=a,b and checks every name against allowed
+func ParseIncludes(c *gin.Context, allowed ...string) (map[string]bool, error) {
+	includes := map[string]bool{}
+	for _, name := range strings.Split(c.Query("include"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !containsString(allowed, name) {
+			return nil, fmt.Errorf("cannot include %q, use one of %s", name, strings.Join(allowed, ", "))
+		}
+		includes[name] = true
+	}
+	return includes, nil
+}
+
+func fieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,383 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter operators
+const (
+	OpEq   = "eq"
+	OpNe   = "ne"
+	OpLike = "like"
+	OpLt   = "lt"
+	OpLte  = "lte"
+	OpGt   = "gt"
+	OpGte  = "gte"
+	OpIn   = "in"
+	OpNin  = "nin"
+	OpNull = "null"
+)
+
+// maxFilterValues caps the values of an in/nin list
+const maxFilterValues = 100
+
+// filterOperators lists the operators each field type accepts
+var filterOperators = map[string][]string{
+	"string": {OpEq, OpNe, OpLike, OpLt, OpLte, OpGt, OpGte, OpIn, OpNin, OpNull},
+	"exact":  {OpEq, OpNe, OpLike, OpLt, OpLte, OpGt, OpGte, OpIn, OpNin, OpNull},
+	"int":    {OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIn, OpNin, OpNull},
+	"float":  {OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIn, OpNin, OpNull},
+	"bool":   {OpEq, OpNe, OpNull},
+	"date":   {OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpNull},
+}
+
+// FilterNode is a node of a parsed filter expression
+type FilterNode interface {
+	filterNode()
+}
+
+// FilterCondition compares a field with a value, Operator is empty when the
+// query used the bare field=value form
+type FilterCondition struct {
+	Key      string // query key the condition comes from, for error messages
+	Field    string
+	Operator string
+	Value    string
+
+	fieldType string
+	args      []interface{}
+	day       bool // date given without a time, compared as a whole day
+}
+
+// FilterAnd matches when all of its nodes match
+type FilterAnd []FilterNode
+
+// FilterOr matches when any of its nodes matches
+type FilterOr []FilterNode
+
+func (*FilterCondition) filterNode() {}
+func (FilterAnd) filterNode()        {}
+func (FilterOr) filterNode()         {}
+
+// FilterError is a filter the client got wrong
+type FilterError struct {
+	Key     string
+	Message string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid filter %q: %s", e.Key, e.Message)
+}
+
+// ParseFilterQuery builds the filter expression from the bracketed query keys:
+//
+//	field[op]=value           a condition, e.g. created_at[gte]=2024-01-01
+//	or[group][field][op]=...  conditions sharing a group are OR'ed
+//
+// The top level conditions and groups are AND'ed. Bare field=value keys are
+// left to the caller since they cannot be told apart from other parameters.
+func ParseFilterQuery(query url.Values) (FilterAnd, error) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := FilterAnd{}
+	groups := map[string]FilterOr{}
+	var groupOrder []string
+	for _, key := range keys {
+		base, segments, err := splitFilterKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(segments) == 0 {
+			continue
+		}
+
+		if base == "or" {
+			if len(segments) < 2 || len(segments) > 3 {
+				return nil, &FilterError{Key: key, Message: "OR conditions are written or[group][field][op]=value"}
+			}
+			group := segments[0]
+			if _, ok := groups[group]; !ok {
+				groupOrder = append(groupOrder, group)
+			}
+			for _, value := range query[key] {
+				groups[group] = append(groups[group], newCondition(key, segments[1], segments[2:], value))
+			}
+			continue
+		}
+
+		if len(segments) > 1 {
+			return nil, &FilterError{Key: key, Message: "conditions are written field[op]=value"}
+		}
+		for _, value := range query[key] {
+			root = append(root, newCondition(key, base, segments, value))
+		}
+	}
+	for _, group := range groupOrder {
+		root = append(root, groups[group])
+	}
+	return root, nil
+}
+
+// ValidateFilter checks every condition against the allowed fields and their
+// types, and converts the values. allowedFields maps a column to its type:
+// string, exact, int, float, bool, date, date_from or date_to.
+func ValidateFilter(node FilterNode, allowedFields map[string]string) error {
+	switch n := node.(type) {
+	case FilterAnd:
+		for _, child := range n {
+			if err := ValidateFilter(child, allowedFields); err != nil {
+				return err
+			}
+		}
+	case FilterOr:
+		for _, child := range n {
+			if err := ValidateFilter(child, allowedFields); err != nil {
+				return err
+			}
+		}
+	case *FilterCondition:
+		return n.validate(allowedFields)
+	}
+	return nil
+}
+
+// FilterSQL renders the expression as a WHERE clause and its arguments
+func FilterSQL(node FilterNode) (string, []interface{}) {
+	switch n := node.(type) {
+	case FilterAnd:
+		return joinFilterSQL(n, " AND ")
+	case FilterOr:
+		return joinFilterSQL(n, " OR ")
+	case *FilterCondition:
+		return n.sql()
+	}
+	return "", nil
+}
+
+func newCondition(key, field string, segments []string, value string) *FilterCondition {
+	condition := &FilterCondition{Key: key, Field: field, Value: value}
+	if len(segments) > 0 {
+		condition.Operator = strings.ToLower(segments[0])
+	}
+	return condition
+}
+
+// splitFilterKey splits "a[b][c]" into "a" and ["b", "c"]
+func splitFilterKey(key string) (string, []string, error) {
+	open := strings.IndexByte(key, '[')
+	if open < 0 {
+		if strings.IndexByte(key, ']') >= 0 {
+			return "", nil, &FilterError{Key: key, Message: "unbalanced brackets"}
+		}
+		return key, nil, nil
+	}
+	base, rest := key[:open], key[open:]
+	if base == "" {
+		return "", nil, &FilterError{Key: key, Message: "missing field name"}
+	}
+
+	var segments []string
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 || strings.IndexByte(rest[1:end], '[') >= 0 {
+			return "", nil, &FilterError{Key: key, Message: "unbalanced brackets"}
+		}
+		segment := rest[1:end]
+		if segment == "" {
+			return "", nil, &FilterError{Key: key, Message: "empty brackets"}
+		}
+		segments = append(segments, segment)
+		rest = rest[end+1:]
+	}
+	return base, segments, nil
+}
+
+func (f *FilterCondition) validate(allowedFields map[string]string) error {
+	fieldType, ok := allowedFields[f.Field]
+	if !ok {
+		return &FilterError{Key: f.Key, Message: fmt.Sprintf("cannot filter by %q", f.Field)}
+	}
+
+	// resolve the default operator of the bare form
+	if f.Operator == "" {
+		switch fieldType {
+		case "string":
+			f.Operator = OpLike
+		case "date_from":
+			f.Operator = OpGte
+		case "date_to":
+			f.Operator = OpLte
+		default:
+			f.Operator = OpEq
+		}
+	}
+	if fieldType == "date_from" || fieldType == "date_to" {
+		fieldType = "date"
+	}
+	f.fieldType = fieldType
+
+	allowed := false
+	for _, op := range filterOperators[fieldType] {
+		allowed = allowed || op == f.Operator
+	}
+	if !allowed {
+		return &FilterError{
+			Key:     f.Key,
+			Message: fmt.Sprintf("operator %q is not supported on %s field %q, use one of %s", f.Operator, fieldType, f.Field, strings.Join(filterOperators[fieldType], ", ")),
+		}
+	}
+
+	// convert the values
+	f.args = nil
+	switch f.Operator {
+	case OpNull:
+		isNull, err := strconv.ParseBool(f.Value)
+		if err != nil {
+			return &FilterError{Key: f.Key, Message: "null expects true or false"}
+		}
+		f.args = []interface{}{isNull}
+		return nil
+
+	case OpIn, OpNin:
+		values := strings.Split(f.Value, ",")
+		if len(values) > maxFilterValues {
+			return &FilterError{Key: f.Key, Message: fmt.Sprintf("at most %d values are allowed", maxFilterValues)}
+		}
+		for _, value := range values {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return &FilterError{Key: f.Key, Message: "empty value in list"}
+			}
+			arg, err := f.convert(value)
+			if err != nil {
+				return err
+			}
+			f.args = append(f.args, arg)
+		}
+		return nil
+	}
+
+	arg, err := f.convert(f.Value)
+	if err != nil {
+		return err
+	}
+	f.args = []interface{}{arg}
+	return nil
+}
+
+func (f *FilterCondition) convert(value string) (interface{}, error) {
+	switch f.fieldType {
+	case "int":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, &FilterError{Key: f.Key, Message: fmt.Sprintf("%q is not an integer", value)}
+		}
+		return n, nil
+
+	case "float":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, &FilterError{Key: f.Key, Message: fmt.Sprintf("%q is not a number", value)}
+		}
+		return n, nil
+
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, &FilterError{Key: f.Key, Message: fmt.Sprintf("%q is not a boolean", value)}
+		}
+		return b, nil
+
+	case "date":
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t, nil
+		}
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, &FilterError{Key: f.Key, Message: fmt.Sprintf("%q is not a date, use YYYY-MM-DD or RFC 3339", value)}
+		}
+		f.day = true
+		return t, nil
+	}
+	return value, nil
+}
+
+func (f *FilterCondition) sql() (string, []interface{}) {
+	column := f.Field
+	text := f.fieldType == "string" || f.fieldType == "exact"
+
+	switch f.Operator {
+	case OpNull:
+		// text columns are stored empty rather than null
+		isNull := f.args[0].(bool)
+		switch {
+		case isNull && text:
+			return fmt.Sprintf("(%s IS NULL OR %s = '')", column, column), nil
+		case isNull:
+			return column + " IS NULL", nil
+		case text:
+			return fmt.Sprintf("(%s IS NOT NULL AND %s <> '')", column, column), nil
+		default:
+			return column + " IS NOT NULL", nil
+		}
+	case OpLike:
+		return column + " ILIKE ?", []interface{}{"%" + escapeLike(f.Value) + "%"}
+	case OpIn:
+		return column + " IN ?", []interface{}{f.args}
+	case OpNin:
+		return column + " NOT IN ?", []interface{}{f.args}
+	}
+
+	// a date without a time covers the whole day
+	if f.day {
+		start := f.args[0].(time.Time)
+		end := start.AddDate(0, 0, 1)
+		switch f.Operator {
+		case OpEq:
+			return fmt.Sprintf("(%s >= ? AND %s < ?)", column, column), []interface{}{start, end}
+		case OpNe:
+			return fmt.Sprintf("(%s < ? OR %s >= ?)", column, column), []interface{}{start, end}
+		case OpLte:
+			return column + " < ?", []interface{}{end}
+		case OpGt:
+			return column + " >= ?", []interface{}{end}
+		}
+	}
+
+	operators := map[string]string{OpEq: "=", OpNe: "<>", OpLt: "<", OpLte: "<=", OpGt: ">", OpGte: ">="}
+	return fmt.Sprintf("%s %s ?", column, operators[f.Operator]), f.args
+}
+
+func joinFilterSQL(nodes []FilterNode, separator string) (string, []interface{}) {
+	var parts []string
+	var args []interface{}
+	for _, node := range nodes {
+		sql, nodeArgs := FilterSQL(node)
+		if sql == "" {
+			continue
+		}
+		parts = append(parts, sql)
+		args = append(args, nodeArgs...)
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	if len(parts) == 1 {
+		return parts[0], args
+	}
+	return "(" + strings.Join(parts, separator) + ")", args
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFilterFields = map[string]string{
+	"name":       "string",
+	"type":       "exact",
+	"level":      "int",
+	"weight":     "float",
+	"shiny":      "bool",
+	"created_at": "date",
+	"from":       "date_from",
+}
+
+func TestParseFilterValues(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query string
+		sql   string
+		args  []interface{}
+	}{
+		{"name=pika", "name ILIKE ?", []interface{}{"%pika%"}},
+		{"name[like]=50%25_off", "name ILIKE ?", []interface{}{`%50\%\_off%`}},
+		{"type=fire", "type = ?", []interface{}{"fire"}},
+		{"level[gte]=5&level[lt]=10", "(level >= ? AND level < ?)", []interface{}{int64(5), int64(10)}},
+		{"level[in]=1, 2,3", "level IN ?", []interface{}{[]interface{}{int64(1), int64(2), int64(3)}}},
+		{"type[nin]=fire,water", "type NOT IN ?", []interface{}{[]interface{}{"fire", "water"}}},
+		{"weight[gte]=12.5&weight[lt]=1e3", "(weight >= ? AND weight < ?)", []interface{}{12.5, 1000.0}},
+		{"weight=7", "weight = ?", []interface{}{7.0}},
+		{"shiny=true", "shiny = ?", []interface{}{true}},
+		{"name[null]=true", "(name IS NULL OR name = '')", nil},
+		{"level[null]=false", "level IS NOT NULL", nil},
+		{"created_at=2024-01-02", "(created_at >= ? AND created_at < ?)", []interface{}{day, day.AddDate(0, 0, 1)}},
+		{"created_at[lte]=2024-01-02", "created_at < ?", []interface{}{day.AddDate(0, 0, 1)}},
+		{"created_at[gt]=2024-01-02T00:00:00Z", "created_at > ?", []interface{}{day}},
+		{"from=2024-01-02", "from >= ?", []interface{}{day}},
+		{"or[a][type]=fire&or[a][type]=water&level=5", "((type = ? OR type = ?) AND level = ?)", []interface{}{"fire", "water", int64(5)}},
+		{"or[b][level][lt]=3&or[a][shiny]=true", "(shiny = ? AND level < ?)", []interface{}{true, int64(3)}},
+		{"page=2&sort_by=name", "", nil},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		filter, err := ParseFilterValues(query, testFilterFields)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		sql, args := FilterSQL(filter)
+		if sql != tt.sql || (len(args) > 0 || len(tt.args) > 0) && !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: got %q %#v, want %q %#v", tt.query, sql, args, tt.sql, tt.args)
+		}
+	}
+}
+
+func TestParseFilterValuesErrors(t *testing.T) {
+	tests := map[string]string{
+		"password[eq]=x":       "cannot filter by",
+		"shiny[gt]=true":       "not supported on bool",
+		"level=abc":            "not an integer",
+		"level[in]=1,,2":       "empty value",
+		"shiny=maybe":          "not a boolean",
+		"weight[gt]=heavy":     "not a number",
+		"weight[gt]=NaN":       "not a number",
+		"weight[like]=1":       "not supported on float",
+		"created_at=yesterday": "not a date",
+		"name[null]=perhaps":   "null expects true or false",
+		"level[in]=" + strings.Repeat("1,", maxFilterValues) + "1": "at most",
+		"name[like":         "unbalanced brackets",
+		"name]":             "unbalanced brackets",
+		"name[]=x":          "empty brackets",
+		"[like]=x":          "missing field name",
+		"name[like][x]=y":   "field[op]=value",
+		"or[a]=x":           "or[group][field][op]=value",
+		"or[a][b][eq][c]=x": "or[group][field][op]=value",
+		"name[a[b]]=x":      "unbalanced brackets",
+	}
+	for raw, want := range tests {
+		query, err := url.ParseQuery(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ParseFilterValues(query, testFilterFields)
+		var filterErr *FilterError
+		if !errors.As(err, &filterErr) || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %v, want a FilterError containing %q", raw, err, want)
+		}
+	}
+}
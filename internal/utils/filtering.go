@@ -4,39 +4,57 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
-	"fmt"
-	"strconv"
+	"net/url"
+	"sort"
 )
 
-// ApplyFilters applies dynamic filters based on allowed fields
-func ApplyFilters(c *gin.Context, db *gorm.DB, allowedFields map[string]string) *gorm.DB {
-	for field, fieldType := range allowedFields {
-		value := c.Query(field)
-		if value == "" {
-			continue
-		}
-
-		switch fieldType {
-		case "string":
-			db = db.Where(fmt.Sprintf("%s ILIKE ?", field), "%"+value+"%")
+// ParseFilters reads the filters of the request and validates them against
+// the allowed fields. Besides the operator syntax of ParseFilterQuery a bare
+// field=value uses the default operator of the field type (contains for
+// string, equals for the others).
+func ParseFilters(c *gin.Context, allowedFields map[string]string) (FilterAnd, error) {
+	return ParseFilterValues(c.Request.URL.Query(), allowedFields)
+}
 
-		case "int":
-			if intValue, err := strconv.Atoi(value); err == nil {
-				db = db.Where(fmt.Sprintf("%s = ?", field), intValue)
-			}
+// ParseFilterValues is ParseFilters for query values saved outside a request
+func ParseFilterValues(query url.Values, allowedFields map[string]string) (FilterAnd, error) {
+	filter, err := ParseFilterQuery(query)
+	if err != nil {
+		return nil, err
+	}
 
-		case "bool":
-			if boolValue, err := strconv.ParseBool(value); err == nil {
-				db = db.Where(fmt.Sprintf("%s = ?", field), boolValue)
-			}
+	// bare keys of allowed fields
+	fields := make([]string, 0, len(allowedFields))
+	for field := range allowedFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if value := query.Get(field); value != "" {
+			filter = append(filter, &FilterCondition{Key: field, Field: field, Value: value})
+		}
+	}
 
-		case "date_from":
-			db = db.Where(fmt.Sprintf("%s >= ?", field), value)
+	if err := ValidateFilter(filter, allowedFields); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
 
-		case "date_to":
-			db = db.Where(fmt.Sprintf("%s <= ?", field), value)
-		}
+// Apply adds the filter to the query
+func (f FilterAnd) Apply(db *gorm.DB) *gorm.DB {
+	if sql, args := FilterSQL(f); sql != "" {
+		db = db.Where(sql, args...)
 	}
 	return db
 }
 
+// ApplyFilters applies dynamic filters based on allowed fields, the error is
+// a *FilterError the client should get as a 400
+func ApplyFilters(c *gin.Context, db *gorm.DB, allowedFields map[string]string) (*gorm.DB, error) {
+	filter, err := ParseFilters(c, allowedFields)
+	if err != nil {
+		return db, err
+	}
+	return filter.Apply(db), nil
+}
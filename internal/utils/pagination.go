@@ -1,10 +1,17 @@
 package utils
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 type DataResponse struct {
@@ -14,10 +21,22 @@ type DataResponse struct {
 	Limit           int         `json:"limit"`
 	HasNextPage     bool        `json:"hasNextPage"`
 	HasPreviousPage bool        `json:"hasPreviousPage"`
+	NextCursor      string      `json:"nextCursor,omitempty"`
+	PrevCursor      string      `json:"prevCursor,omitempty"`
 	Items           interface{} `json:"items"`
 }
 
+// MaxLimit caps the page size of every list
+const MaxLimit = 100
+
+// Pagination modes
+const (
+	OffsetPagination = "offset"
+	KeysetPagination = "keyset"
+)
+
 type Pagination struct {
+	Mode            string
 	Limit           int
 	Page            int
 	Sort            string
@@ -26,19 +45,68 @@ type Pagination struct {
 	HasPreviousPage bool
 	TotalItems      int64
 	TotalPages      int
+	NextCursor      string
+	PrevCursor      string
+	Err             error // invalid sort, or invalid cursor in keyset mode
+
+	fetchExtra bool // one extra row is fetched to detect the next page
+	sortField  *schema.Field
+	idField    *schema.Field
+	desc       bool
+	cursor     *Cursor
 }
 
-func ApplyPagination(c *gin.Context, db *gorm.DB, model interface{}) (*gorm.DB, Pagination) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+// ApplyPagination pages the query by offset (page, limit) or, when a cursor
+// or paginate=cursor is given, by keyset on the sort column and the id.
+// include_total=false skips the COUNT in offset mode, include_total=true
+// adds it in keyset mode. The sort column must be one of sortable, so a
+// list never orders by, or puts in its cursors, a column it does not show.
+// Call FinalizePagination after fetching the items.
+func ApplyPagination(c *gin.Context, db *gorm.DB, model interface{}, sortable map[string]bool) (*gorm.DB, Pagination) {
+	return ApplyPaginationValues(c.Request.URL.Query(), db, model, sortable)
+}
+
+// ApplyPaginationValues is ApplyPagination reading the parameters from query
+func ApplyPaginationValues(query url.Values, db *gorm.DB, model interface{}, sortable map[string]bool) (*gorm.DB, Pagination) {
+	limit, _ := strconv.Atoi(queryDefault(query, "limit", "10"))
+	page, _ := strconv.Atoi(queryDefault(query, "page", "1"))
 	if limit <= 0 {
 		limit = 10
 	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
 	if page <= 0 {
 		page = 1
 	}
 
-	sort := c.DefaultQuery("sort", "id desc")
+	if isKeyset(query) {
+		return applyKeyset(query, db, model, limit, sortable)
+	}
+
+	column, desc, err := parseSort(queryDefault(query, "sort", "id desc"))
+	if err == nil && !sortable[column] {
+		err = fmt.Errorf("cannot sort by %q", column)
+	}
+	if err != nil {
+		return db, Pagination{Mode: OffsetPagination, Limit: limit, Page: page, Err: err}
+	}
+	sort := column + " " + direction(desc)
+
+	// counting can be skipped, the next page is then detected with an extra row
+	if query.Get("include_total") == "false" {
+		pagination := Pagination{
+			Mode:            OffsetPagination,
+			Limit:           limit,
+			Page:            page,
+			Sort:            sort,
+			Offset:          (page - 1) * limit,
+			HasPreviousPage: page > 1,
+			fetchExtra:      true,
+		}
+		db = db.Order(sort).Limit(limit + 1).Offset(pagination.Offset)
+		return db, pagination
+	}
 
 	var totalItems int64
 	db.Model(model).Count(&totalItems)
@@ -53,6 +121,7 @@ func ApplyPagination(c *gin.Context, db *gorm.DB, model interface{}) (*gorm.DB,
 	offset := (page - 1) * limit
 
 	pagination := Pagination{
+		Mode:            OffsetPagination,
 		Limit:           limit,
 		Page:            page,
 		Sort:            sort,
@@ -66,3 +135,190 @@ func ApplyPagination(c *gin.Context, db *gorm.DB, model interface{}) (*gorm.DB,
 	db = db.Order(sort).Limit(limit).Offset(offset)
 	return db, pagination
 }
+
+// IsKeysetPagination reports whether the request asks for keyset pagination
+func IsKeysetPagination(c *gin.Context) bool {
+	return isKeyset(c.Request.URL.Query())
+}
+
+func isKeyset(query url.Values) bool {
+	return query.Get("cursor") != "" || query.Get("paginate") == "cursor"
+}
+
+// queryDefault is gin's DefaultQuery on url.Values
+func queryDefault(query url.Values, key, defaultValue string) string {
+	if values, ok := query[key]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return defaultValue
+}
+
+// FinalizePagination trims the extra row, restores the order of a backward
+// page and computes the cursors. items must be a pointer to the fetched slice.
+func FinalizePagination(pagination *Pagination, items interface{}) {
+	rows := reflect.ValueOf(items).Elem()
+
+	hasMore := false
+	if pagination.fetchExtra && rows.Len() > pagination.Limit {
+		hasMore = true
+		rows.Set(rows.Slice(0, pagination.Limit))
+	}
+
+	if pagination.Mode != KeysetPagination {
+		if pagination.fetchExtra {
+			pagination.HasNextPage = hasMore
+		}
+		return
+	}
+
+	backward := pagination.cursor != nil && pagination.cursor.Backward
+	if backward {
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			first, last := rows.Index(i).Interface(), rows.Index(j).Interface()
+			rows.Index(i).Set(reflect.ValueOf(last))
+			rows.Index(j).Set(reflect.ValueOf(first))
+		}
+	}
+
+	// a backward page always has a next page, a forward one has a previous
+	// page whenever it was reached through a cursor
+	pagination.HasNextPage = hasMore || backward
+	pagination.HasPreviousPage = (backward && hasMore) || (!backward && pagination.cursor != nil)
+
+	if rows.Len() == 0 {
+		return
+	}
+	if pagination.HasNextPage {
+		pagination.NextCursor = pagination.cursorAt(rows.Index(rows.Len()-1), false)
+	}
+	if pagination.HasPreviousPage {
+		pagination.PrevCursor = pagination.cursorAt(rows.Index(0), true)
+	}
+}
+
+// Columns lists the columns the cursors are computed from, they must be
+// selected when the query is narrowed to a fieldset
+func (p *Pagination) Columns() []string {
+	if p.Mode != KeysetPagination || p.sortField == nil {
+		return nil
+	}
+	return []string{p.idField.DBName, p.sortField.DBName}
+}
+
+func applyKeyset(query url.Values, db *gorm.DB, model interface{}, limit int, sortable map[string]bool) (*gorm.DB, Pagination) {
+	pagination := Pagination{Mode: KeysetPagination, Limit: limit, fetchExtra: true}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		pagination.Err = err
+		return db, pagination
+	}
+
+	// the sort column must be a sortable column of the model
+	column, desc, err := keysetSort(query)
+	if err == nil && (!sortable[column] || stmt.Schema.LookUpField(column) == nil) {
+		err = fmt.Errorf("cannot sort by %q", column)
+	}
+	if err != nil {
+		pagination.Err = err
+		return db, pagination
+	}
+	pagination.sortField = stmt.Schema.LookUpField(column)
+	pagination.idField = stmt.Schema.PrioritizedPrimaryField
+	pagination.desc = desc
+	pagination.Sort = column + " " + direction(desc)
+
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := DecodeCursor(raw)
+		if err == nil && (cursor.Column != pagination.sortField.DBName || cursor.Desc != desc) {
+			err = errors.New("cursor does not match the sort order")
+		}
+		if err != nil {
+			pagination.Err = err
+			return db, pagination
+		}
+		pagination.cursor = cursor
+	}
+
+	if query.Get("include_total") == "true" {
+		db.Model(model).Count(&pagination.TotalItems)
+	}
+
+	// walk backward by flipping the comparison and the order
+	scanDesc := desc
+	if pagination.cursor != nil && pagination.cursor.Backward {
+		scanDesc = !desc
+	}
+	operator := ">"
+	if scanDesc {
+		operator = "<"
+	}
+
+	table := stmt.Schema.Table
+	sortColumn := table + "." + pagination.sortField.DBName
+	idColumn := table + "." + pagination.idField.DBName
+	if cursor := pagination.cursor; cursor != nil {
+		if sortColumn == idColumn {
+			db = db.Where(fmt.Sprintf("%s %s ?", idColumn, operator), cursor.ID)
+		} else {
+			db = db.Where(
+				fmt.Sprintf("(%s %s ?) OR (%s = ? AND %s %s ?)", sortColumn, operator, sortColumn, idColumn, operator),
+				cursor.Value, cursor.Value, cursor.ID,
+			)
+		}
+	}
+
+	db = db.Order(sortColumn + " " + direction(scanDesc))
+	if sortColumn != idColumn {
+		db = db.Order(idColumn + " " + direction(scanDesc))
+	}
+	return db.Limit(limit + 1), pagination
+}
+
+// keysetSort reads the sort column from sort_by/order or from sort
+func keysetSort(query url.Values) (string, bool, error) {
+	column, order := query.Get("sort_by"), queryDefault(query, "order", "asc")
+	if column == "" {
+		return parseSort(queryDefault(query, "sort", "id desc"))
+	}
+	if order != "asc" && order != "desc" {
+		return "", false, errors.New("order must be asc or desc")
+	}
+	return column, order == "desc", nil
+}
+
+// parseSort reads "<column> [asc|desc]"
+func parseSort(sort string) (string, bool, error) {
+	parts := strings.Fields(sort)
+	if len(parts) == 0 || len(parts) > 2 {
+		return "", false, errors.New("sort must be \"<column> [asc|desc]\"")
+	}
+	order := "asc"
+	if len(parts) == 2 {
+		order = strings.ToLower(parts[1])
+	}
+	if order != "asc" && order != "desc" {
+		return "", false, errors.New("order must be asc or desc")
+	}
+	return parts[0], order == "desc", nil
+}
+
+func (p *Pagination) cursorAt(row reflect.Value, backward bool) string {
+	ctx := context.Background()
+	value, _ := p.sortField.ValueOf(ctx, row)
+	id, _ := p.idField.ValueOf(ctx, row)
+	return EncodeCursor(Cursor{
+		Column:   p.sortField.DBName,
+		Desc:     p.desc,
+		Value:    value,
+		ID:       id,
+		Backward: backward,
+	})
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "desc"
+	}
+	return "asc"
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Patch media types accepted by PATCH endpoints
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// PatchError carries the status code a failed patch should answer with
+type PatchError struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+func (e *PatchError) Error() string {
+	return e.Message
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyPatch applies the request body to doc, which must be a pointer to a
+// struct holding the current state. The patched document is validated with
+// its binding tags and the json names of the changed fields are returned.
+func ApplyPatch(c *gin.Context, doc interface{}) ([]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != MergePatchType && mediaType != JSONPatchType {
+		return nil, &PatchError{
+			Code:    http.StatusUnsupportedMediaType,
+			Message: fmt.Sprintf("Content-Type must be %s or %s", MergePatchType, JSONPatchType),
+		}
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, &PatchError{Code: http.StatusBadRequest, Message: "Failed to read patch", Err: err}
+	}
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the patch on the json representation
+	var patched []byte
+	if mediaType == MergePatchType {
+		patched, err = jsonpatch.MergePatch(original, patch)
+	} else {
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(patch)
+		if err == nil {
+			patched, err = operations.Apply(original)
+		}
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return nil, &PatchError{Code: http.StatusConflict, Message: "Patch test operation failed", Err: err}
+	}
+	if err != nil {
+		return nil, &PatchError{Code: http.StatusBadRequest, Message: "Invalid patch: " + err.Error(), Err: err}
+	}
+
+	// decode into a fresh copy so removed fields fall back to zero values
+	before := reflect.ValueOf(doc).Elem()
+	after := reflect.New(before.Type())
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(after.Interface()); err != nil {
+		return nil, &PatchError{Code: http.StatusUnprocessableEntity, Message: "Invalid patched document: " + err.Error(), Err: err}
+	}
+	if err := binding.Validator.ValidateStruct(after.Interface()); err != nil {
+		return nil, err
+	}
+
+	// collect the changed fields by their json name
+	var changed []string
+	for i := 0; i < before.NumField(); i++ {
+		if !reflect.DeepEqual(before.Field(i).Interface(), after.Elem().Field(i).Interface()) {
+			changed = append(changed, jsonName(before.Type().Field(i)))
+		}
+	}
+	before.Set(after.Elem())
+	return changed, nil
+}
+
+// PatchErrorResponse answers a failed ApplyPatch
+func PatchErrorResponse(c *gin.Context, err error) {
+	var patchErr *PatchError
+	if errors.As(err, &patchErr) {
+		Response(c, patchErr.Code, false, patchErr.Message, nil)
+		return
+	}
+	ValidationErrorResponse(c, err)
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
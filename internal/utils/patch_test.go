@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testPatchDoc struct {
+	Name  string   `json:"name" binding:"required"`
+	Notes string   `json:"notes,omitempty"`
+	Shiny bool     `json:"shiny"`
+	Tags  []string `json:"tags"`
+	Level int
+}
+
+func patchContext(contentType, body string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", contentType)
+	return c
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        testPatchDoc
+		changed     []string
+	}{
+		{"merge", MergePatchType, `{"shiny":true,"notes":null}`, testPatchDoc{Name: "eevee", Shiny: true, Tags: []string{"cute"}, Level: 5}, []string{"notes", "shiny"}},
+		{"merge with charset", MergePatchType + "; charset=utf-8", `{"Level":6}`, testPatchDoc{Name: "eevee", Notes: "x", Tags: []string{"cute"}, Level: 6}, []string{"Level"}},
+		{"json patch", JSONPatchType, `[{"op":"test","path":"/name","value":"eevee"},{"op":"add","path":"/tags/-","value":"fluffy"},{"op":"replace","path":"/name","value":"vaporeon"}]`, testPatchDoc{Name: "vaporeon", Notes: "x", Tags: []string{"cute", "fluffy"}, Level: 5}, []string{"name", "tags"}},
+		{"no change", MergePatchType, `{"name":"eevee"}`, testPatchDoc{Name: "eevee", Notes: "x", Tags: []string{"cute"}, Level: 5}, nil},
+	}
+	for _, tt := range tests {
+		doc := testPatchDoc{Name: "eevee", Notes: "x", Tags: []string{"cute"}, Level: 5}
+		changed, err := ApplyPatch(patchContext(tt.contentType, tt.body), &doc)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(doc, tt.want) || !reflect.DeepEqual(changed, tt.changed) {
+			t.Errorf("%s: got %+v %v, want %+v %v", tt.name, doc, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		code        int
+	}{
+		{"plain json", "application/json", `{"shiny":true}`, http.StatusUnsupportedMediaType},
+		{"malformed merge", MergePatchType, `{"shiny":`, http.StatusBadRequest},
+		{"malformed json patch", JSONPatchType, `{"op":"add"}`, http.StatusBadRequest},
+		{"missing path", JSONPatchType, `[{"op":"remove","path":"/nope"}]`, http.StatusBadRequest},
+		{"failed test", JSONPatchType, `[{"op":"test","path":"/name","value":"pikachu"}]`, http.StatusConflict},
+		{"unknown field", MergePatchType, `{"password":"x"}`, http.StatusUnprocessableEntity},
+		{"wrong type", MergePatchType, `{"shiny":"yes"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		doc := testPatchDoc{Name: "eevee"}
+		_, err := ApplyPatch(patchContext(tt.contentType, tt.body), &doc)
+		var patchErr *PatchError
+		if !errors.As(err, &patchErr) || patchErr.Code != tt.code {
+			t.Errorf("%s: error = %v, want a PatchError with code %d", tt.name, err, tt.code)
+		}
+		if !reflect.DeepEqual(doc, testPatchDoc{Name: "eevee"}) {
+			t.Errorf("%s: doc changed to %+v", tt.name, doc)
+		}
+	}
+
+	// the patched document is validated with its binding tags
+	doc := testPatchDoc{Name: "eevee"}
+	_, err := ApplyPatch(patchContext(MergePatchType, `{"name":""}`), &doc)
+	var patchErr *PatchError
+	if err == nil || errors.As(err, &patchErr) || doc.Name != "eevee" {
+		t.Errorf("removing a required field = %v, doc %+v", err, doc)
+	}
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResourceID reads the id from the path (v2) or the id query (v1).
+// Missing or malformed ids are rejected instead of matching any row.
+func ResourceID(c *gin.Context) (uint, bool) {
+	id := c.Param("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	value, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || value == 0 {
+		return 0, false
+	}
+	return uint(value), true
+}
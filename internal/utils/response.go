@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -16,24 +18,39 @@ type BaseResponse struct {
 }
 
 func ValidationErrorResponse(c *gin.Context, err error) {
-	var errors []string
+	// v2 answers semantic validation errors with 422
+	code := http.StatusBadRequest
+	if APIVersion(c) >= 2 {
+		code = http.StatusUnprocessableEntity
+	}
+
 	if ve, ok := err.(validator.ValidationErrors); ok {
-		for _, e := range ve {
-			switch e.Tag() {
-			case "required":
-				errors = append(errors, fmt.Sprintf("%s is required", e.Field()))
-			case "min":
-				errors = append(errors, fmt.Sprintf("%s must be at least %s characters", e.Field(), e.Param()))
-			default:
-				errors = append(errors, e.Field()+": "+e.Tag()+" "+e.Param())
-			}
-		}
-		Response(c, 400, false, "Validation failed", gin.H{
-			"validation_errors": errors,
+		Response(c, code, false, "Validation failed", gin.H{
+			"validation_errors": ValidationMessages(ve),
 		})
 	} else {
-		Response(c, 400, false, err.Error(), nil)
+		Response(c, http.StatusBadRequest, false, err.Error(), nil)
+	}
+}
+
+// ValidationMessages turns binding errors into readable messages
+func ValidationMessages(err error) []string {
+	ve, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []string{err.Error()}
+	}
+	var errors []string
+	for _, e := range ve {
+		switch e.Tag() {
+		case "required":
+			errors = append(errors, fmt.Sprintf("%s is required", e.Field()))
+		case "min":
+			errors = append(errors, fmt.Sprintf("%s must be at least %s characters", e.Field(), e.Param()))
+		default:
+			errors = append(errors, e.Field()+": "+e.Tag()+" "+e.Param())
+		}
 	}
+	return errors
 }
 
 func Response(c *gin.Context, code int, success bool, message string, data interface{}) {
@@ -46,3 +63,41 @@ func Response(c *gin.Context, code int, success bool, message string, data inter
 
 	c.JSON(code, response)
 }
+
+// StatusError is a failure of an operation shared by several transports,
+// carrying the HTTP status it is answered with
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// ErrorResponse answers a StatusError with its status, other errors with 500
+func ErrorResponse(c *gin.Context, err error) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		Response(c, statusErr.Code, false, statusErr.Message, nil)
+		return
+	}
+	Response(c, http.StatusInternalServerError, false, err.Error(), nil)
+}
+
+// Deleted answers a successful delete, v2 responds 204 without a body
+func Deleted(c *gin.Context, message string, data interface{}) {
+	if APIVersion(c) >= 2 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	Response(c, http.StatusOK, true, message, data)
+}
+
+// APIVersion returns the API version of the route tree, defaults to 1
+func APIVersion(c *gin.Context) int {
+	if version := c.GetInt("apiVersion"); version > 0 {
+		return version
+	}
+	return 1
+}
@@ -0,0 +1,184 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api/config"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Settings tune the delivery of the webhooks
+type Settings struct {
+	MaxAttempts  int           // attempts before a delivery is dead
+	Backoff      time.Duration // delay after the first failure, doubled after each one
+	MaxBackoff   time.Duration
+	Timeout      time.Duration // of one attempt
+	PollInterval time.Duration // between two scans of the queue
+}
+
+// settings are read by Start
+var settings Settings
+
+// wakeup makes the dispatcher scan the queue without waiting for the poll
+var wakeup = make(chan struct{}, 1)
+
+// workers caps the deliveries attempted at once
+var workers = make(chan struct{}, 4)
+
+// LoadSettings reads the settings from the environment
+func LoadSettings() Settings {
+	return Settings{
+		MaxAttempts:  config.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),                   // Default 8 attempts
+		Backoff:      config.GetEnvDuration("WEBHOOK_BACKOFF", 30*time.Second),      // Default 30s, 1m, 2m...
+		MaxBackoff:   config.GetEnvDuration("WEBHOOK_MAX_BACKOFF", 6*time.Hour),     // Default cap
+		Timeout:      config.GetEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),      // Default timeout
+		PollInterval: config.GetEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second), // Default poll
+	}
+}
+
+// Start delivers the queued deliveries until ctx is done. Deliveries are
+// claimed in the database, so several instances can share the queue.
+func Start(ctx context.Context, db *gorm.DB, s Settings) {
+	settings = s
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	for {
+		dispatch(ctx, db)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wakeup:
+		}
+	}
+}
+
+func wake() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch attempts the deliveries that are due
+func dispatch(ctx context.Context, db *gorm.DB) {
+	var due []models.WebhookDelivery
+	err := db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(100).
+		Find(&due).Error
+	if err != nil {
+		log.Printf("webhooks: failed to read the queue: %v", err)
+		return
+	}
+
+	for _, delivery := range due {
+		// the claim pushes the next attempt past the timeout, a crashed
+		// attempt is retried once it passes
+		claimed := db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.DeliveryPending, delivery.NextAttemptAt).
+			Update("next_attempt_at", time.Now().Add(2*settings.Timeout))
+		if claimed.Error != nil || claimed.RowsAffected == 0 {
+			continue
+		}
+
+		workers <- struct{}{}
+		go func(delivery models.WebhookDelivery) {
+			defer func() { <-workers }()
+			attempt(ctx, db, delivery)
+		}(delivery)
+	}
+}
+
+// attempt posts the delivery and records the outcome
+func attempt(ctx context.Context, db *gorm.DB, delivery models.WebhookDelivery) {
+	var hook models.Webhook
+	err := db.Unscoped().First(&hook, delivery.WebhookID).Error
+	switch {
+	case err != nil:
+		err = errors.New("webhook not found")
+	case hook.DeletedAt.Valid:
+		err = errors.New("webhook deleted")
+	case !hook.Active:
+		err = errors.New("webhook disabled")
+	}
+	if err != nil {
+		db.Model(&delivery).Updates(map[string]interface{}{"status": models.DeliveryDead, "last_error": err.Error()})
+		return
+	}
+
+	code, response, err := post(ctx, hook, delivery)
+	updates := map[string]interface{}{
+		"attempts":         delivery.Attempts + 1,
+		"last_status_code": code,
+		"last_response":    response,
+		"last_error":       "",
+	}
+	switch {
+	case err == nil:
+		now := time.Now()
+		updates["status"] = models.DeliverySucceeded
+		updates["delivered_at"] = &now
+	case delivery.Attempts+1 >= settings.MaxAttempts:
+		updates["status"] = models.DeliveryDead
+		updates["last_error"] = err.Error()
+	default:
+		updates["next_attempt_at"] = time.Now().Add(backoff(delivery.Attempts + 1))
+		updates["last_error"] = err.Error()
+	}
+	db.Model(&delivery).Updates(updates)
+}
+
+// post sends the signed payload, any status but 2xx is a failure
+func post(ctx context.Context, hook models.Webhook, delivery models.WebhookDelivery) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, settings.Timeout)
+	defer cancel()
+
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PokeAPI-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, payload))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(response), fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(response), nil
+}
+
+// backoff is the delay before the next attempt, doubling from Backoff up to
+// MaxBackoff with a jitter of up to 10%
+func backoff(attempts int) time.Duration {
+	delay := settings.Backoff
+	for i := 1; i < attempts && delay < settings.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > settings.MaxBackoff {
+		delay = settings.MaxBackoff
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/10+1))
+}
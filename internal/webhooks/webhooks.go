@@ -0,0 +1,151 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api/config"
+	"go-api/internal/events"
+	"go-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PingEvent is the event type of the deliveries sent by Ping
+const PingEvent = "webhook.ping"
+
+// Signature headers of a delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// body is the JSON posted to the webhooks
+type body struct {
+	Type       string      `json:"type"`
+	UserEmail  string      `json:"userEmail,omitempty"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// Register queues a delivery for every webhook subscribed to an event
+func Register() {
+	events.Subscribe("webhooks", func(e events.Event) error {
+		return Enqueue(config.DB, e)
+	}, events.Types...)
+}
+
+// Enqueue queues the event for the active webhooks subscribed to its type,
+// for all of them or for none so a retry queues no webhook twice
+func Enqueue(db *gorm.DB, e events.Event) error {
+	var hooks []models.Webhook
+	if err := db.Where("active = ?", true).Find(&hooks).Error; err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, hook := range hooks {
+			if !Subscribed(hook, e.Type) {
+				continue
+			}
+			if _, err := queue(tx, hook, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Ping queues a test delivery for the webhook, whatever its event types
+func Ping(db *gorm.DB, hook models.Webhook) (models.WebhookDelivery, error) {
+	return queue(db, hook, events.Event{
+		Type:       PingEvent,
+		OccurredAt: time.Now(),
+		Payload:    map[string]interface{}{"webhookId": hook.ID},
+	})
+}
+
+// Replay queues a copy of a delivery, the original stays in the log
+func Replay(db *gorm.DB, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	replay := models.WebhookDelivery{
+		WebhookID:     delivery.WebhookID,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+		ReplayOf:      &delivery.ID,
+	}
+	if err := db.Create(&replay).Error; err != nil {
+		return replay, err
+	}
+	wake()
+	return replay, nil
+}
+
+// Subscribed reports whether the webhook listens to the event type
+func Subscribed(hook models.Webhook, eventType string) bool {
+	for _, subscribed := range strings.Split(hook.EventTypes, ",") {
+		if subscribed = strings.TrimSpace(subscribed); subscribed == "*" || subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func queue(db *gorm.DB, hook models.Webhook, e events.Event) (models.WebhookDelivery, error) {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	payload, err := json.Marshal(body{Type: e.Type, UserEmail: e.UserEmail, OccurredAt: e.OccurredAt, Data: e.Payload})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	delivery := models.WebhookDelivery{
+		WebhookID:     hook.ID,
+		EventType:     e.Type,
+		Payload:       string(payload),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		return delivery, err
+	}
+	wake()
+	return delivery, nil
+}
+
+// NewSecret returns a random webhook secret
+func NewSecret() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return "whsec_" + hex.EncodeToString(buf)
+}
+
+// Sign returns the signature of a body sent at timestamp (unix seconds)
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature headers of a received delivery, refusing
+// timestamps further than tolerance from now to stop replays
+func Verify(secret, timestamp, signature string, payload []byte, tolerance time.Duration) bool {
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(sent, 0)); age > tolerance || age < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, sent, payload)), []byte(signature))
+}
@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	got := Sign("whsec_test", 1700000000, []byte(`{"type":"pokemon.created"}`))
+	if want := "sha256=b261bf6dc805d2027a93441a54f7a97192ebdffabfc6dc0d2bd619878154f2a7"; got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	payload := []byte(`{"type":"pokemon.created"}`)
+	now := time.Now().Unix()
+	signature := Sign("whsec_test", now, payload)
+	stamp := strconv.FormatInt(now, 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		payload   string
+		want      bool
+	}{
+		{"valid", "whsec_test", stamp, signature, string(payload), true},
+		{"other secret", "whsec_other", stamp, signature, string(payload), false},
+		{"tampered body", "whsec_test", stamp, signature, `{"type":"pokemon.deleted"}`, false},
+		{"other timestamp", "whsec_test", strconv.FormatInt(now-1, 10), signature, string(payload), false},
+		{"bad timestamp", "whsec_test", "yesterday", signature, string(payload), false},
+		{"missing prefix", "whsec_test", stamp, signature[len("sha256="):], string(payload), false},
+		{"empty signature", "whsec_test", stamp, "", string(payload), false},
+	}
+	for _, tt := range tests {
+		if got := Verify(tt.secret, tt.timestamp, tt.signature, []byte(tt.payload), 5*time.Minute); got != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTolerance(t *testing.T) {
+	payload := []byte(`{}`)
+	for _, tt := range []struct {
+		age  time.Duration
+		want bool
+	}{
+		{4 * time.Minute, true},
+		{-4 * time.Minute, true},
+		{6 * time.Minute, false},
+		{-6 * time.Minute, false},
+	} {
+		sent := time.Now().Add(-tt.age).Unix()
+		got := Verify("whsec_test", strconv.FormatInt(sent, 10), Sign("whsec_test", sent, payload), payload, 5*time.Minute)
+		if got != tt.want {
+			t.Errorf("Verify of a delivery %v old = %v, want %v", tt.age, got, tt.want)
+		}
+	}
+}